@@ -3,6 +3,7 @@ module github.com/zaydek/retro
 go 1.16
 
 require (
+	github.com/andybalholm/brotli v1.0.5
 	github.com/buildkite/terminal-to-html/v3 v3.6.1
 	github.com/evanw/esbuild v0.11.6
 )
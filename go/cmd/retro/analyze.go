@@ -0,0 +1,410 @@
+package retro
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zaydek/retro/go/cmd/retro/unix"
+	"github.com/zaydek/retro/go/pkg/terminal"
+)
+
+// The package name for inputs outside of 'node_modules'
+const appPackageName = "(app)"
+
+// The number of packages and modules logged to stdout
+const analyzeTopN = 10
+
+// The default HTML report filename, relative to the output directory
+const analyzeReportFilename = "retro-analyze.html"
+
+// Gets the package directory for an input path, e.g.
+// 'node_modules/a/node_modules/b/index.js' is 'node_modules/a/node_modules/b'.
+// Inputs outside of 'node_modules' return an empty string.
+func getPackageDir(path string) string {
+	path = filepath.ToSlash(path)
+	index := strings.LastIndex(path, "node_modules/")
+	if index == -1 {
+		return ""
+	}
+	start := index + len("node_modules/")
+	parts := strings.Split(path[start:], "/")
+	n := len(parts[0])
+	if strings.HasPrefix(parts[0], "@") && len(parts) > 1 {
+		n += len("/") + len(parts[1])
+	}
+	return path[:start+n]
+}
+
+// Gets the package name for an input path, e.g. 'node_modules/react/index.js'
+// is 'react'. Nested packages use the innermost 'node_modules' directory.
+func getPackageName(path string) string {
+	dir := getPackageDir(path)
+	if dir == "" {
+		return appPackageName
+	}
+	return dir[strings.LastIndex(dir, "node_modules/")+len("node_modules/"):]
+}
+
+type analyzeModule struct {
+	path  string
+	pkg   string
+	bytes int64
+}
+
+type analyzePackage struct {
+	name    string
+	bytes   int64
+	modules []analyzeModule // Sorted by bytes
+}
+
+// Describes which packages and modules make up the JS and CSS outputs
+type analyzeReport struct {
+	total    int64
+	packages []analyzePackage // Sorted by bytes
+	modules  []analyzeModule  // Sorted by bytes
+}
+
+// Sorts by bytes then path so reports are stable
+func sortModules(modules []analyzeModule) {
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].bytes != modules[j].bytes {
+			return modules[i].bytes > modules[j].bytes
+		}
+		return modules[i].path < modules[j].path
+	})
+}
+
+// Creates a report from the vendor and client metafiles. Bytes are bytes in
+// the output, not bytes on disk.
+func newAnalyzeReport(msg Message) (analyzeReport, error) {
+	byModule := map[string]int64{}
+	for _, info := range []BundleInfo{msg.VendorInfo, msg.ClientInfo} {
+		meta, err := info.getMetafile()
+		if err != nil {
+			return analyzeReport{}, err
+		}
+		for path, output := range meta.Outputs {
+			if ext := filepath.Ext(path); ext != ".js" && ext != ".css" {
+				continue
+			}
+			for input, in := range output.Inputs {
+				byModule[input] += in.BytesInOutput
+			}
+		}
+	}
+
+	var report analyzeReport
+	byPackage := map[string]*analyzePackage{}
+	for path, size := range byModule {
+		if size == 0 {
+			continue
+		}
+		module := analyzeModule{path: path, pkg: getPackageName(path), bytes: size}
+		report.modules = append(report.modules, module)
+		report.total += size
+		pkg, ok := byPackage[module.pkg]
+		if !ok {
+			pkg = &analyzePackage{name: module.pkg}
+			byPackage[module.pkg] = pkg
+		}
+		pkg.bytes += size
+		pkg.modules = append(pkg.modules, module)
+	}
+	sortModules(report.modules)
+	for _, pkg := range byPackage {
+		sortModules(pkg.modules)
+		report.packages = append(report.packages, *pkg)
+	}
+	sort.Slice(report.packages, func(i, j int) bool {
+		if report.packages[i].bytes != report.packages[j].bytes {
+			return report.packages[i].bytes > report.packages[j].bytes
+		}
+		return report.packages[i].name < report.packages[j].name
+	})
+	return report, nil
+}
+
+func (r analyzeReport) percent(size int64) float64 {
+	if r.total == 0 {
+		return 0
+	}
+	return float64(size) / float64(r.total) * 100
+}
+
+func (r analyzeReport) String() string {
+	return r.format(terminal.Width())
+}
+
+// Formats the report as tables fit to width
+func (r analyzeReport) format(width int) string {
+	const sizeWidth = len("  XXXX.X KB")
+	const percentWidth = len("  100.0%")
+
+	var out string
+	formatTable := func(title string, names []string, sizes []int64) {
+		nameWidth := len(title)
+		for _, name := range names {
+			if len(name) > nameWidth {
+				nameWidth = len(name)
+			}
+		}
+		nameWidth = getColumnWidth(nameWidth, width-sizeWidth-percentWidth)
+		out += fmt.Sprintln(terminal.Bold(title) + spaces(nameWidth-len(title)))
+		for index, name := range names {
+			name = truncateStart(name, nameWidth)
+			out += fmt.Sprintln(name + spaces(nameWidth-len(name)) +
+				terminal.Dimf("%*s%*.1f%%", sizeWidth, unix.HumanReadable(sizes[index]), percentWidth-1, r.percent(sizes[index])))
+		}
+		out += fmt.Sprintln()
+	}
+
+	var (
+		names []string
+		sizes []int64
+	)
+	for index, pkg := range r.packages {
+		if index == analyzeTopN {
+			break
+		}
+		names = append(names, pkg.name)
+		sizes = append(sizes, pkg.bytes)
+	}
+	formatTable("Top packages", names, sizes)
+
+	names, sizes = nil, nil
+	for index, module := range r.modules {
+		if index == analyzeTopN {
+			break
+		}
+		names = append(names, module.path)
+		sizes = append(sizes, module.bytes)
+	}
+	formatTable("Top modules", names, sizes)
+
+	out += fmt.Sprintln(terminal.Dimf("%s in %d packages and %d modules", unix.HumanReadable(r.total), len(r.packages), len(r.modules)))
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type treemapRect struct {
+	x, y, w, h float64
+}
+
+// Gets the worst aspect ratio of a row of areas laid along side
+func worstRatio(areas []float64, side float64) float64 {
+	var sum, min, max float64
+	for index, area := range areas {
+		sum += area
+		if index == 0 || area < min {
+			min = area
+		}
+		if area > max {
+			max = area
+		}
+	}
+	side2, sum2 := side*side, sum*sum
+	if a, b := side2*max/sum2, sum2/(side2*min); a > b {
+		return a
+	} else {
+		return b
+	}
+}
+
+// Lays out values sorted in descending order as a squarified treemap in rect
+//
+// https://www.win.tue.nl/~vanwijk/stm.pdf
+func squarify(values []float64, rect treemapRect) []treemapRect {
+	var total float64
+	for _, value := range values {
+		total += value
+	}
+	rects := make([]treemapRect, len(values))
+	if total <= 0 || rect.w <= 0 || rect.h <= 0 {
+		return rects
+	}
+	areas := make([]float64, len(values))
+	for index, value := range values {
+		areas[index] = value * rect.w * rect.h / total
+	}
+
+	start := 0
+	for start < len(areas) {
+		side := rect.w
+		if rect.h < side {
+			side = rect.h
+		}
+		end := start + 1
+		for end < len(areas) && areas[end] > 0 && worstRatio(areas[start:end+1], side) <= worstRatio(areas[start:end], side) {
+			end++
+		}
+		var sum float64
+		for _, area := range areas[start:end] {
+			sum += area
+		}
+		if rect.w >= rect.h {
+			// Lay out a column on the left
+			w := sum / rect.h
+			y := rect.y
+			for index := start; index < end; index++ {
+				h := areas[index] / w
+				rects[index] = treemapRect{x: rect.x, y: y, w: w, h: h}
+				y += h
+			}
+			rect.x += w
+			rect.w -= w
+		} else {
+			// Lay out a row on the top
+			h := sum / rect.w
+			x := rect.x
+			for index := start; index < end; index++ {
+				w := areas[index] / h
+				rects[index] = treemapRect{x: x, y: rect.y, w: w, h: h}
+				x += w
+			}
+			rect.y += h
+			rect.h -= h
+		}
+		start = end
+	}
+	return rects
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Percentages relative to the parent element
+type treemapNode struct {
+	Left, Top, Width, Height float64
+
+	Name    string
+	Size    string
+	Percent string
+	Hue     int
+	Modules []treemapNode
+}
+
+type treemapTableRow struct {
+	Name    string
+	Size    string
+	Percent string
+}
+
+type treemapData struct {
+	Total    string
+	Packages []treemapNode
+	Rows     []treemapTableRow
+}
+
+var analyzeTemplate = template.Must(template.New("analyze").Parse(`<!DOCTYPE html>
+<html lang="en">
+	<head>
+		<meta charset="utf-8" />
+		<meta name="viewport" content="width=device-width, initial-scale=1" />
+		<title>Retro analyze</title>
+		<style>
+			* { box-sizing: border-box; }
+			body { margin: 0; padding: 24px; font: 13px/1.4 system-ui, sans-serif; color: #222; }
+			h1 { margin: 0 0 4px; font-size: 18px; }
+			p { margin: 0 0 16px; color: #666; }
+			.treemap { position: relative; width: 100%; height: 70vh; min-height: 400px; }
+			.package, .module { position: absolute; overflow: hidden; }
+			.package { border: 1px solid #fff; }
+			.package > span { position: absolute; top: 2px; left: 4px; z-index: 1; font-weight: 600; white-space: nowrap; pointer-events: none; }
+			.module { border: 1px solid rgba(255, 255, 255, 0.6); }
+			.module:hover { filter: brightness(0.9); }
+			table { margin-top: 24px; border-collapse: collapse; }
+			td, th { padding: 2px 12px 2px 0; text-align: left; }
+			td:not(:first-child), th:not(:first-child) { text-align: right; font-variant-numeric: tabular-nums; }
+		</style>
+	</head>
+	<body>
+		<h1>Retro analyze</h1>
+		<p>{{.Total}} in the output. Hover a module to see its path and size.</p>
+		<div class="treemap">
+			{{- range .Packages}}
+			<div class="package" style="left: {{.Left}}%; top: {{.Top}}%; width: {{.Width}}%; height: {{.Height}}%;" title="{{.Name}} ({{.Size}}, {{.Percent}})">
+				<span>{{.Name}}</span>
+				{{- $hue := .Hue}}
+				{{- range .Modules}}
+				<div class="module" style="left: {{.Left}}%; top: {{.Top}}%; width: {{.Width}}%; height: {{.Height}}%; background-color: hsl({{$hue}}, 55%, 75%);" title="{{.Name}} ({{.Size}}, {{.Percent}})"></div>
+				{{- end}}
+			</div>
+			{{- end}}
+		</div>
+		<table>
+			<thead>
+				<tr><th>Package</th><th>Size</th><th>%</th></tr>
+			</thead>
+			<tbody>
+				{{- range .Rows}}
+				<tr><td>{{.Name}}</td><td>{{.Size}}</td><td>{{.Percent}}</td></tr>
+				{{- end}}
+			</tbody>
+		</table>
+	</body>
+</html>
+`))
+
+// Renders a self-contained HTML treemap of packages and their modules
+func (r analyzeReport) HTML() (string, error) {
+	data := treemapData{Total: unix.HumanReadable(r.total)}
+
+	var pkgSizes []float64
+	for _, pkg := range r.packages {
+		pkgSizes = append(pkgSizes, float64(pkg.bytes))
+	}
+	for pkgIndex, rect := range squarify(pkgSizes, treemapRect{w: 100, h: 100}) {
+		pkg := r.packages[pkgIndex]
+		node := treemapNode{
+			Left:    rect.x,
+			Top:     rect.y,
+			Width:   rect.w,
+			Height:  rect.h,
+			Name:    pkg.name,
+			Size:    unix.HumanReadable(pkg.bytes),
+			Percent: fmt.Sprintf("%.1f%%", r.percent(pkg.bytes)),
+			Hue:     (pkgIndex * 47) % 360,
+		}
+		var moduleSizes []float64
+		for _, module := range pkg.modules {
+			moduleSizes = append(moduleSizes, float64(module.bytes))
+		}
+		for moduleIndex, rect := range squarify(moduleSizes, treemapRect{w: 100, h: 100}) {
+			module := pkg.modules[moduleIndex]
+			node.Modules = append(node.Modules, treemapNode{
+				Left:    rect.x,
+				Top:     rect.y,
+				Width:   rect.w,
+				Height:  rect.h,
+				Name:    module.path,
+				Size:    unix.HumanReadable(module.bytes),
+				Percent: fmt.Sprintf("%.1f%%", r.percent(module.bytes)),
+			})
+		}
+		data.Packages = append(data.Packages, node)
+		data.Rows = append(data.Rows, treemapTableRow{Name: node.Name, Size: node.Size, Percent: node.Percent})
+	}
+
+	var buf bytes.Buffer
+	if err := analyzeTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Writes the HTML report to filename
+func (r analyzeReport) writeHTML(filename string) error {
+	html, err := r.HTML()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, []byte(html), 0644)
+}
@@ -0,0 +1,119 @@
+package retro
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestGetPackageName(t *testing.T) {
+	expect.DeepEqual(t, getPackageName("src/App.js"), appPackageName)
+	expect.DeepEqual(t, getPackageName("node_modules/react/index.js"), "react")
+	expect.DeepEqual(t, getPackageName("node_modules/@emotion/react/dist/index.js"), "@emotion/react")
+	expect.DeepEqual(t, getPackageName("node_modules/a/node_modules/b/index.js"), "b")
+}
+
+func TestNewAnalyzeReport(t *testing.T) {
+	msg := Message{
+		VendorInfo: BundleInfo{Metafile: map[string]interface{}{
+			"outputs": map[string]interface{}{
+				"out/vendor.js": map[string]interface{}{
+					"inputs": map[string]interface{}{
+						"node_modules/react/index.js":         map[string]interface{}{"bytesInOutput": 100},
+						"node_modules/react/cjs/react.js":     map[string]interface{}{"bytesInOutput": 300},
+						"node_modules/react-dom/index.js":     map[string]interface{}{"bytesInOutput": 200},
+						"node_modules/object-assign/index.js": map[string]interface{}{"bytesInOutput": 0},
+					},
+				},
+				"out/vendor.js.map": map[string]interface{}{
+					"inputs": map[string]interface{}{},
+				},
+			},
+		}},
+		ClientInfo: BundleInfo{Metafile: map[string]interface{}{
+			"outputs": map[string]interface{}{
+				"out/client.js": map[string]interface{}{
+					"inputs": map[string]interface{}{
+						"src/App.js": map[string]interface{}{"bytesInOutput": 400},
+					},
+				},
+			},
+		}},
+	}
+	report, err := newAnalyzeReport(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect.DeepEqual(t, report.total, int64(1000))
+	expect.DeepEqual(t, len(report.modules), 4)
+	expect.DeepEqual(t, report.modules[0], analyzeModule{path: "src/App.js", pkg: appPackageName, bytes: 400})
+
+	// Ties sort by name
+	var names []string
+	for _, pkg := range report.packages {
+		names = append(names, pkg.name)
+	}
+	expect.DeepEqual(t, names, []string{appPackageName, "react", "react-dom"})
+	expect.DeepEqual(t, report.packages[1].bytes, int64(400))
+	expect.DeepEqual(t, report.percent(400), 40.0)
+}
+
+func TestSquarify(t *testing.T) {
+	values := []float64{6, 6, 4, 3, 2, 2, 1}
+	rects := squarify(values, treemapRect{w: 6, h: 4})
+	expect.DeepEqual(t, len(rects), len(values))
+	for index, rect := range rects {
+		// Areas are proportional to values; 6x4 sums to 24
+		if math.Abs(rect.w*rect.h-values[index]) > 1e-9 {
+			t.Errorf("rect %d has area %f; want %f", index, rect.w*rect.h, values[index])
+		}
+		if rect.x < -1e-9 || rect.y < -1e-9 || rect.x+rect.w > 6+1e-9 || rect.y+rect.h > 4+1e-9 {
+			t.Errorf("rect %d is out of bounds: %+v", index, rect)
+		}
+	}
+	expect.DeepEqual(t, squarify(nil, treemapRect{w: 1, h: 1}), []treemapRect{})
+}
+
+func TestAnalyzeReportHTML(t *testing.T) {
+	report := analyzeReport{
+		total: 10,
+		packages: []analyzePackage{
+			{name: "<script>", bytes: 10, modules: []analyzeModule{{path: "node_modules/<script>/index.js", pkg: "<script>", bytes: 10}}},
+		},
+	}
+	html, err := report.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect.DeepEqual(t, strings.Contains(html, "<script>"), false)
+	expect.DeepEqual(t, strings.Contains(html, "&lt;script&gt;"), true)
+}
+
+func TestGetPackageDir(t *testing.T) {
+	expect.DeepEqual(t, getPackageDir("src/App.js"), "")
+	expect.DeepEqual(t, getPackageDir("node_modules/react/index.js"), "node_modules/react")
+	expect.DeepEqual(t, getPackageDir("node_modules/@emotion/react/dist/index.js"), "node_modules/@emotion/react")
+	expect.DeepEqual(t, getPackageDir("node_modules/a/node_modules/b/index.js"), "node_modules/a/node_modules/b")
+}
+
+func TestAnalyzeReportFormat(t *testing.T) {
+	module := analyzeModule{path: "node_modules/react-dom/cjs/react-dom.production.min.js", pkg: "react-dom", bytes: 100}
+	report := analyzeReport{
+		total:    100,
+		packages: []analyzePackage{{name: "react-dom", bytes: 100, modules: []analyzeModule{module}}},
+		modules:  []analyzeModule{module},
+	}
+
+	// Tiny terminals clamp the name column instead of panicking
+	for _, width := range []int{200, 20, 0, -1} {
+		str := stripANSI(report.format(width))
+		expect.DeepEqual(t, strings.Contains(str, "Top packages"), true)
+		expect.DeepEqual(t, strings.Contains(str, "react-dom"), true)
+	}
+	str := stripANSI(report.format(0))
+	expect.DeepEqual(t, strings.Contains(str, "...uction.min.js"), true)
+	str = stripANSI(report.format(200))
+	expect.DeepEqual(t, strings.Contains(str, module.path), true)
+}
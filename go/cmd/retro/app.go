@@ -1,13 +1,18 @@
 package retro
 
-import "github.com/zaydek/retro/go/cmd/retro/cli"
+import (
+	"os"
+
+	"github.com/zaydek/retro/go/cmd/retro/cli"
+)
 
 type CommandKind string
 
 var (
-	KindDevCommand   CommandKind = "dev"
-	KindBuildCommand CommandKind = "build"
-	KindServeCommand CommandKind = "serve"
+	KindDevCommand     CommandKind = "dev"
+	KindBuildCommand   CommandKind = "build"
+	KindServeCommand   CommandKind = "serve"
+	KindAnalyzeCommand CommandKind = "analyze"
 )
 
 type App struct {
@@ -15,7 +20,7 @@ type App struct {
 	Command interface{}
 }
 
-// Gets the app's command kind; one of dev, build, or serve
+// Gets the app's command kind; one of dev, build, serve, or analyze
 func (a *App) getCommandKind() CommandKind {
 	var zeroValue CommandKind
 	switch a.Command.(type) {
@@ -25,6 +30,8 @@ func (a *App) getCommandKind() CommandKind {
 		return KindBuildCommand
 	case cli.ServeCommand:
 		return KindServeCommand
+	case cli.AnalyzeCommand:
+		return KindAnalyzeCommand
 	}
 	return zeroValue
 }
@@ -39,3 +46,98 @@ func (a *App) getPort() int {
 	}
 	return zeroValue
 }
+
+// Gets the app's base path flag or an empty string
+func (a *App) getBase() string {
+	switch command := a.Command.(type) {
+	case cli.DevCommand:
+		return command.Base
+	case cli.BuildCommand:
+		return command.Base
+	case cli.ServeCommand:
+		return command.Base
+	}
+	return ""
+}
+
+// Gets the app's '--src', '--www', and '--out' flags or empty strings
+func (a *App) getDirs() (srcDir, wwwDir, outDir string) {
+	switch command := a.Command.(type) {
+	case cli.DevCommand:
+		return command.SrcDir, command.WWWDir, command.OutDir
+	case cli.BuildCommand:
+		return command.SrcDir, command.WWWDir, command.OutDir
+	case cli.ServeCommand:
+		return command.SrcDir, command.WWWDir, command.OutDir
+	}
+	return "", "", ""
+}
+
+// Gets the app's sourcemap flag or an empty string
+func (a *App) getSourcemap() string {
+	switch command := a.Command.(type) {
+	case cli.DevCommand:
+		return command.Sourcemap
+	case cli.BuildCommand:
+		return command.Sourcemap
+	}
+	return ""
+}
+
+// Gets the app's '--warnings-as-errors' flag
+func (a *App) getWarningsAsErrors() bool {
+	switch command := a.Command.(type) {
+	case cli.DevCommand:
+		return command.WarningsAsErrors
+	case cli.BuildCommand:
+		return command.WarningsAsErrors
+	}
+	return false
+}
+
+// Gets the app's '--force' flag
+func (a *App) getForce() bool {
+	switch command := a.Command.(type) {
+	case cli.DevCommand:
+		return command.Force
+	case cli.BuildCommand:
+		return command.Force
+	case cli.AnalyzeCommand:
+		return command.Force
+	}
+	return false
+}
+
+// Sets environment variables from flags so flags take precedence over the
+// environment
+func (a *App) setEnvFromFlags() error {
+	if base := a.getBase(); base != "" {
+		if err := os.Setenv("RETRO_BASE", base); err != nil {
+			return err
+		}
+	}
+	srcDir, wwwDir, outDir := a.getDirs()
+	for envKey, dir := range map[string]string{
+		"RETRO_SRC_DIR": srcDir,
+		"RETRO_WWW_DIR": wwwDir,
+		"RETRO_OUT_DIR": outDir,
+	} {
+		if dir == "" {
+			continue
+		}
+		if err := os.Setenv(envKey, dir); err != nil {
+			return err
+		}
+	}
+	if sourcemap := a.getSourcemap(); sourcemap != "" {
+		if err := os.Setenv("RETRO_SOURCEMAP", sourcemap); err != nil {
+			return err
+		}
+	}
+	if command, ok := a.Command.(cli.BuildCommand); ok && command.Watch {
+		if err := os.Setenv("RETRO_WATCH", "true"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,11 +1,14 @@
 package retro
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
 	render "github.com/buildkite/terminal-to-html/v3"
 	"github.com/evanw/esbuild/pkg/api"
+	"github.com/zaydek/retro/go/cmd/format"
 )
 
 type BundleInfo struct {
@@ -14,8 +17,21 @@ type BundleInfo struct {
 	Warnings []api.Message
 }
 
+// Treats warnings as errors; set by '--warnings-as-errors'
+var warningsAsErrors bool
+
+func (b BundleInfo) HasErrors() bool {
+	return len(b.Errors) > 0
+}
+
+func (b BundleInfo) HasWarnings() bool {
+	return len(b.Warnings) > 0
+}
+
+// Dirty bundles block the build. Warnings are logged but only block the build
+// when warnings are treated as errors.
 func (b BundleInfo) IsDirty() bool {
-	return len(b.Errors) > 0 || len(b.Warnings) > 0
+	return b.HasErrors() || (warningsAsErrors && b.HasWarnings())
 }
 
 func (b BundleInfo) String() string {
@@ -89,29 +105,55 @@ a:hover { text-decoration: underline; }
 	</head>
 	<body>
 		<pre><code>` + renderStr + `</pre></code>
-		` + htmlServerSentEvents + `
+		` + getHTMLServerSentEvents() + `
 	</body>
 </html>`
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// Describes a prerendered route
+type RouteInfo struct {
+	Route string // e.g. '/about'
+	HTML  string // The markup rendered by ReactDOMServer
+}
+
 type Message struct {
-	VendorInfo BundleInfo
-	ClientInfo BundleInfo
+	EsbuildVersion string
+	VendorInfo     BundleInfo
+	ClientInfo     BundleInfo
+	PrerenderInfo  BundleInfo  // Errors and warnings from prerendering; has no metafile
+	Routes         []RouteInfo // Prerendered routes
 }
 
 func (m Message) IsDirty() bool {
-	return m.VendorInfo.IsDirty() || m.ClientInfo.IsDirty()
+	return m.VendorInfo.IsDirty() || m.ClientInfo.IsDirty() || m.PrerenderInfo.IsDirty()
+}
+
+func (m Message) HasWarnings() bool {
+	return m.VendorInfo.HasWarnings() || m.ClientInfo.HasWarnings() || m.PrerenderInfo.HasWarnings()
 }
 
+// Formats errors and warnings from the vendor and client bundles and
+// prerendering
 func (m Message) String() string {
-	if m.VendorInfo.IsDirty() {
-		return m.VendorInfo.String()
-	} else if m.ClientInfo.IsDirty() {
-		return m.ClientInfo.String()
+	var strs []string
+	for _, info := range []BundleInfo{m.VendorInfo, m.ClientInfo, m.PrerenderInfo} {
+		if str := info.String(); str != "" {
+			strs = append(strs, str)
+		}
 	}
-	return ""
+	return strings.Join(strs, "\n\n")
+}
+
+// Gets errors from the vendor and client bundles and prerendering
+func (m Message) allErrors() []api.Message {
+	return append(append(append([]api.Message{}, m.VendorInfo.Errors...), m.ClientInfo.Errors...), m.PrerenderInfo.Errors...)
+}
+
+// Gets warnings from the vendor and client bundles and prerendering
+func (m Message) allWarnings() []api.Message {
+	return append(append(append([]api.Message{}, m.VendorInfo.Warnings...), m.ClientInfo.Warnings...), m.PrerenderInfo.Warnings...)
 }
 
 func (m Message) HTML() string {
@@ -119,14 +161,34 @@ func (m Message) HTML() string {
 		return m.VendorInfo.HTML()
 	} else if m.ClientInfo.IsDirty() {
 		return m.ClientInfo.HTML()
+	} else if m.PrerenderInfo.IsDirty() {
+		return m.PrerenderInfo.HTML()
 	}
 	return ""
 }
 
+// Gets the index page's outputs
 func (m Message) getChunkedEntrypoints() entryPoints {
+	return m.getPageEntrypoints(newIndexPage())
+}
+
+// Gets every page's outputs in the order of pages
+func (m Message) getAllPageEntrypoints() []entryPoints {
+	var all []entryPoints
+	for _, p := range pages {
+		all = append(all, m.getPageEntrypoints(p))
+	}
+	return all
+}
+
+// Gets a page's outputs. Every page shares the vendor bundle; client outputs
+// are matched by entry name, e.g. 'pages/pricing__ABCD1234.js' for
+// 'pages/pricing'.
+func (m Message) getPageEntrypoints(p page) entryPoints {
+	name := p.entryName()
 	if RETRO_CMD == string(KindDevCommand) {
 		if m.VendorInfo.Metafile == nil || m.ClientInfo.Metafile == nil {
-			return entryPoints{"client.css", "vendor.js", "client.js"}
+			return entryPoints{name + ".css", "vendor.js", name + ".js"}
 		}
 	}
 	var entries entryPoints
@@ -137,17 +199,38 @@ func (m Message) getChunkedEntrypoints() entryPoints {
 		}
 	}
 	for key := range m.ClientInfo.Metafile["outputs"].(map[string]interface{}) {
-		if strings.HasSuffix(key, ".css") {
-			entries.clientCSS, _ = filepath.Rel(RETRO_OUT_DIR, key)
-			if entries.clientJS != "" { // Check other
-				break
-			}
-		} else if strings.HasSuffix(key, ".js") {
-			entries.clientJS, _ = filepath.Rel(RETRO_OUT_DIR, key)
-			if entries.clientCSS != "" { // Check other
-				break
-			}
+		rel, _ := filepath.Rel(RETRO_OUT_DIR, key)
+		switch getLogicalFilename(filepath.ToSlash(rel)) {
+		case name + ".css":
+			entries.clientCSS = rel
+		case name + ".js":
+			entries.clientJS = rel
 		}
 	}
 	return entries
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Describes a failed build; either esbuild errored or the backend wrote to
+// stderr
+type buildError struct {
+	msg    Message
+	stderr string
+}
+
+func (e buildError) Error() string {
+	if e.stderr != "" {
+		return e.stderr
+	}
+	return e.msg.String()
+}
+
+// Logs to stdout for esbuild errors and to stderr for backend errors
+func (e buildError) log() {
+	if e.stderr != "" {
+		fmt.Fprintln(os.Stderr, format.StderrIPC(e.stderr))
+		return
+	}
+	fmt.Print(e.msg.String())
+}
@@ -0,0 +1,41 @@
+package retro
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestIsDirty(t *testing.T) {
+	defer func() { warningsAsErrors = false }()
+
+	var (
+		clean    = Message{}
+		warnings = Message{ClientInfo: BundleInfo{Warnings: []api.Message{{Text: "Unused"}}}}
+		errors   = Message{VendorInfo: BundleInfo{Errors: []api.Message{{Text: "Oops"}}}}
+	)
+
+	warningsAsErrors = false
+	expect.DeepEqual(t, clean.IsDirty(), false)
+	expect.DeepEqual(t, warnings.IsDirty(), false)
+	expect.DeepEqual(t, warnings.HasWarnings(), true)
+	expect.DeepEqual(t, errors.IsDirty(), true)
+
+	warningsAsErrors = true
+	expect.DeepEqual(t, clean.IsDirty(), false)
+	expect.DeepEqual(t, warnings.IsDirty(), true)
+	expect.DeepEqual(t, errors.IsDirty(), true)
+}
+
+func TestMessageString(t *testing.T) {
+	msg := Message{
+		VendorInfo: BundleInfo{Errors: []api.Message{{Text: "Oops"}}},
+		ClientInfo: BundleInfo{Warnings: []api.Message{{Text: "Unused"}}},
+	}
+	str := stripANSI(msg.String())
+	expect.DeepEqual(t, strings.Contains(str, "Oops"), true)
+	expect.DeepEqual(t, strings.Contains(str, "Unused"), true)
+	expect.DeepEqual(t, Message{}.String(), "")
+}
@@ -0,0 +1,176 @@
+package retro
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zaydek/retro/go/cmd/retro/unix"
+	"github.com/zaydek/retro/go/pkg/manifest"
+)
+
+// Describes a number of bytes. Sizes can be numbers or strings such as
+// '200 KB'.
+type byteSize int64
+
+var byteSizeRegex = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*(B|KB|MB|GB)?\s*$`)
+
+func (b *byteSize) UnmarshalJSON(bstr []byte) error {
+	var n float64
+	if err := json.Unmarshal(bstr, &n); err == nil {
+		*b = byteSize(n)
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(bstr, &str); err != nil {
+		return err
+	}
+	matches := byteSizeRegex.FindStringSubmatch(strings.ToUpper(str))
+	if matches == nil {
+		return fmt.Errorf("bad size %q; use bytes or a string such as \"200 KB\"", str)
+	}
+	n, _ = strconv.ParseFloat(matches[1], 64)
+	switch matches[2] {
+	case "KB":
+		n *= 1024
+	case "MB":
+		n *= 1024 * 1024
+	case "GB":
+		n *= 1024 * 1024 * 1024
+	}
+	*b = byteSize(n)
+	return nil
+}
+
+// Describes a size budget. Zero sizes are not checked.
+type BudgetConfig struct {
+	Raw  byteSize `json:"raw"`
+	Gzip byteSize `json:"gzip"`
+	Hard bool     `json:"hard"` // Fail the build when exceeded
+}
+
+// Describes size budgets per output kind
+type BudgetsConfig struct {
+	VendorJS *BudgetConfig `json:"vendorJS"`
+	ClientJS *BudgetConfig `json:"clientJS"` // Includes chunks
+	CSS      *BudgetConfig `json:"css"`
+	Total    *BudgetConfig `json:"total"`
+}
+
+// Describes an exceeded budget
+type budgetViolation struct {
+	name   string // e.g. 'client JS'
+	metric string // One of "raw" or "gzip"
+	size   int64
+	limit  int64
+	hard   bool
+	paths  []string
+}
+
+func (v budgetViolation) String() string {
+	var hard string
+	if v.hard {
+		hard = " (hard)"
+	}
+	return fmt.Sprintf("%s is %s %s; the budget is %s%s",
+		v.name,
+		unix.HumanReadable(v.size),
+		v.metric,
+		unix.HumanReadable(v.limit),
+		hard,
+	)
+}
+
+type budgetGroup struct {
+	name   string
+	budget *BudgetConfig
+	kinds  []manifest.OutputKind
+}
+
+// Checks outputs from the metafiles against the configured budgets and returns
+// exceeded budgets. Paths refer to the output directory, not the staging
+// directory.
+func checkBudgets(msg Message) ([]budgetViolation, error) {
+	budgets := userConfig.Budgets
+	if budgets == (BudgetsConfig{}) {
+		return nil, nil
+	}
+	groups := []budgetGroup{
+		{name: "vendor JS", budget: budgets.VendorJS, kinds: []manifest.OutputKind{manifest.KindVendorJS}},
+		{name: "client JS", budget: budgets.ClientJS, kinds: []manifest.OutputKind{manifest.KindClientJS, manifest.KindChunk}},
+		{name: "CSS", budget: budgets.CSS, kinds: []manifest.OutputKind{manifest.KindClientCSS}},
+		{name: "total", budget: budgets.Total, kinds: []manifest.OutputKind{manifest.KindVendorJS, manifest.KindClientJS, manifest.KindChunk, manifest.KindClientCSS}},
+	}
+
+	type output struct {
+		kind manifest.OutputKind
+		raw  int64
+		gzip int64
+	}
+	outputs := map[string]output{}
+	for _, bundle := range []struct {
+		info     BundleInfo
+		isVendor bool
+	}{
+		{info: msg.VendorInfo, isVendor: true},
+		{info: msg.ClientInfo, isVendor: false},
+	} {
+		meta, err := bundle.info.getMetafile()
+		if err != nil {
+			return nil, err
+		}
+		for path, out := range meta.Outputs {
+			kind := getOutputKind(path, out, bundle.isVendor)
+			if kind == manifest.KindSourcemap || kind == manifest.KindAsset {
+				continue
+			}
+			gz, err := gzipSize(path)
+			if err != nil {
+				return nil, err
+			}
+			outputs[getUnstagedPath(filepath.Clean(path))] = output{kind: kind, raw: out.Bytes, gzip: gz}
+		}
+	}
+
+	var violations []budgetViolation
+	for _, group := range groups {
+		if group.budget == nil {
+			continue
+		}
+		var (
+			raw   int64
+			gzip  int64
+			paths []string
+		)
+		for path, out := range outputs {
+			for _, kind := range group.kinds {
+				if out.kind == kind {
+					raw += out.raw
+					gzip += out.gzip
+					paths = append(paths, path)
+				}
+			}
+		}
+		sort.Strings(paths)
+		if limit := int64(group.budget.Raw); limit > 0 && raw > limit {
+			violations = append(violations, budgetViolation{name: group.name, metric: "raw", size: raw, limit: limit, hard: group.budget.Hard, paths: paths})
+		}
+		if limit := int64(group.budget.Gzip); limit > 0 && gzip > limit {
+			violations = append(violations, budgetViolation{name: group.name, metric: "gzip", size: gzip, limit: limit, hard: group.budget.Hard, paths: paths})
+		}
+	}
+	return violations, nil
+}
+
+func hasHardViolation(violations []budgetViolation) bool {
+	for _, v := range violations {
+		if v.hard {
+			return true
+		}
+	}
+	return false
+}
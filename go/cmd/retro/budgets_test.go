@@ -0,0 +1,122 @@
+package retro
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestByteSize(t *testing.T) {
+	var budget BudgetConfig
+
+	if err := json.Unmarshal([]byte(`{"raw": 1000, "gzip": "1.5 KB"}`), &budget); err != nil {
+		t.Fatal(err)
+	}
+	expect.DeepEqual(t, budget, BudgetConfig{Raw: 1000, Gzip: 1536})
+
+	if err := json.Unmarshal([]byte(`{"raw": "2mb"}`), &budget); err != nil {
+		t.Fatal(err)
+	}
+	expect.DeepEqual(t, budget.Raw, byteSize(2*1024*1024))
+
+	err := json.Unmarshal([]byte(`{"raw": "lots"}`), &budget)
+	expect.NotDeepEqual(t, err, nil)
+}
+
+func TestCheckBudgets(t *testing.T) {
+	root := t.TempDir()
+	prevOut, prevStaged, prevConfig := RETRO_OUT_DIR, stagedOutDir, userConfig
+	t.Cleanup(func() { RETRO_OUT_DIR, stagedOutDir, userConfig = prevOut, prevStaged, prevConfig })
+
+	// Check a staged build so paths are rebased onto the output directory
+	RETRO_OUT_DIR, stagedOutDir = filepath.Join(root, "out.staging"), filepath.Join(root, "out")
+	must(os.MkdirAll(RETRO_OUT_DIR, 0755))
+	var (
+		staged = func(name string) string { return filepath.Join(RETRO_OUT_DIR, name) }
+		out    = func(name string) string { return filepath.Join(stagedOutDir, name) }
+	)
+	sizes := map[string]int{
+		"vendor__ABCD1234.js":     1000,
+		"client__EFGH5678.js":     500,
+		"chunk-IJKL9012.js":       300,
+		"client__MNOP3456.css":    200,
+		"client__EFGH5678.js.map": 5000, // Not budgeted
+		"logo-QRST7890.png":       5000, // Not budgeted
+	}
+	for name, size := range sizes {
+		must(os.WriteFile(staged(name), []byte(strings.Repeat("a", size)), 0644))
+	}
+	gz, err := gzipSize(staged("vendor__ABCD1234.js"))
+	must(err)
+
+	output := func(name, entryPoint string) map[string]interface{} {
+		return map[string]interface{}{"bytes": sizes[name], "entryPoint": entryPoint}
+	}
+	msg := Message{
+		VendorInfo: BundleInfo{Metafile: map[string]interface{}{
+			"outputs": map[string]interface{}{
+				staged("vendor__ABCD1234.js"): output("vendor__ABCD1234.js", "vendor.js"),
+			},
+		}},
+		ClientInfo: BundleInfo{Metafile: map[string]interface{}{
+			"outputs": map[string]interface{}{
+				staged("client__EFGH5678.js"):     output("client__EFGH5678.js", "src/index.js"),
+				staged("chunk-IJKL9012.js"):       output("chunk-IJKL9012.js", ""),
+				staged("client__MNOP3456.css"):    output("client__MNOP3456.css", ""),
+				staged("client__EFGH5678.js.map"): output("client__EFGH5678.js.map", ""),
+				staged("logo-QRST7890.png"):       output("logo-QRST7890.png", ""),
+			},
+		}},
+	}
+
+	// No budgets
+	userConfig.Budgets = BudgetsConfig{}
+	violations, err := checkBudgets(msg)
+	must(err)
+	expect.DeepEqual(t, len(violations), 0)
+
+	// Budgets under their limits
+	userConfig.Budgets = BudgetsConfig{
+		VendorJS: &BudgetConfig{Raw: 1000, Gzip: byteSize(gz)},
+		ClientJS: &BudgetConfig{Raw: 800},
+		CSS:      &BudgetConfig{Raw: 200, Hard: true},
+		Total:    &BudgetConfig{Raw: 2000},
+	}
+	violations, err = checkBudgets(msg)
+	must(err)
+	expect.DeepEqual(t, len(violations), 0)
+
+	// Soft raw budgets match outputs by kind; client JS includes chunks, and
+	// sourcemaps and assets are never budgeted
+	userConfig.Budgets = BudgetsConfig{
+		ClientJS: &BudgetConfig{Raw: 799},
+		Total:    &BudgetConfig{Raw: 1999},
+	}
+	violations, err = checkBudgets(msg)
+	must(err)
+	expect.DeepEqual(t, violations, []budgetViolation{
+		{name: "client JS", metric: "raw", size: 800, limit: 799, paths: []string{out("chunk-IJKL9012.js"), out("client__EFGH5678.js")}},
+		{name: "total", metric: "raw", size: 2000, limit: 1999, paths: []string{
+			out("chunk-IJKL9012.js"),
+			out("client__EFGH5678.js"),
+			out("client__MNOP3456.css"),
+			out("vendor__ABCD1234.js"),
+		}},
+	})
+	expect.DeepEqual(t, hasHardViolation(violations), false)
+
+	// Hard gzip budgets
+	userConfig.Budgets = BudgetsConfig{
+		VendorJS: &BudgetConfig{Raw: 1000, Gzip: byteSize(gz - 1), Hard: true},
+	}
+	violations, err = checkBudgets(msg)
+	must(err)
+	expect.DeepEqual(t, violations, []budgetViolation{
+		{name: "vendor JS", metric: "gzip", size: gz, limit: gz - 1, hard: true, paths: []string{out("vendor__ABCD1234.js")}},
+	})
+	expect.DeepEqual(t, hasHardViolation(violations), true)
+}
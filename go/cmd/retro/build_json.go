@@ -0,0 +1,206 @@
+package retro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/zaydek/retro/go/cmd/format"
+)
+
+// Exit codes for 'retro build'
+const (
+	exitCodeSuccess      = 0
+	exitCodeFailure      = 1 // The build failed
+	exitCodeChecksFailed = 2 // The build succeeded but a hard budget or '--strict-dedupe' failed; it is discarded
+)
+
+// Returned from a staged build when checks failed so the build is discarded
+var errChecksFailed = errors.New("checks failed")
+
+// Checks whether a hard budget or '--strict-dedupe' failed
+func hasFailedChecks(violations []budgetViolation, duplicates []duplicatePackage, strictDedupe bool) bool {
+	return hasHardViolation(violations) || (strictDedupe && len(duplicates) > 0)
+}
+
+type buildStatus string
+
+const (
+	buildStatusSuccess      buildStatus = "success"
+	buildStatusFailure      buildStatus = "failure"
+	buildStatusChecksFailed buildStatus = "checks-failed"
+)
+
+type buildJSONOutput struct {
+	Path   string `json:"path"` // Relative to the output directory
+	Bytes  int64  `json:"bytes"`
+	Gzip   int64  `json:"gzip"`
+	Brotli int64  `json:"brotli,omitempty"` // Zero unless precompressed
+}
+
+type buildJSONBudget struct {
+	Name   string `json:"name"`
+	Metric string `json:"metric"`
+	Bytes  int64  `json:"bytes"`
+	Limit  int64  `json:"limit"`
+	Hard   bool   `json:"hard"`
+}
+
+type buildJSONPackageCopy struct {
+	Dir     string `json:"dir"`
+	Version string `json:"version"`
+	Bytes   int64  `json:"bytes"`
+}
+
+type buildJSONDuplicate struct {
+	Name   string                 `json:"name"`
+	Copies []buildJSONPackageCopy `json:"copies"`
+	Wasted int64                  `json:"wasted"`
+}
+
+// Describes the result of 'retro build --json'. Errors and warnings use
+// esbuild's message type so locations are preserved.
+type buildJSON struct {
+	Status     buildStatus          `json:"status"`
+	ExitCode   int                  `json:"exitCode"`
+	DurationMs int64                `json:"durationMs"`
+	Sourcemap  string               `json:"sourcemap"` // One of "none", "linked", "external", "inline", or "hidden"
+	Outputs    []buildJSONOutput    `json:"outputs"`
+	Errors     []api.Message        `json:"errors"`
+	Warnings   []api.Message        `json:"warnings"`
+	Budgets    []buildJSONBudget    `json:"budgets"`    // Exceeded budgets
+	Duplicates []buildJSONDuplicate `json:"duplicates"` // Packages bundled more than once
+}
+
+// Matches ANSI escape codes
+var ansiRegex = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+func stripANSI(str string) string {
+	return ansiRegex.ReplaceAllString(str, "")
+}
+
+// Checks for 'retro build --json' before arguments are parsed so argument
+// errors are logged as JSON, too
+func isBuildJSON(args []string) bool {
+	if len(args) == 0 || args[0] != "build" {
+		return false
+	}
+	for _, arg := range args[1:] {
+		if arg == "--json" || arg == "--json=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// Creates a result for a build that failed before producing outputs
+func newBuildJSONFailure(err error, dur time.Duration) buildJSON {
+	result := buildJSON{
+		Status:     buildStatusFailure,
+		ExitCode:   exitCodeFailure,
+		DurationMs: dur.Milliseconds(),
+	}
+	if buildErr, ok := err.(buildError); ok && buildErr.stderr == "" {
+		result.Errors = buildErr.msg.allErrors()
+		result.Warnings = buildErr.msg.allWarnings()
+	} else {
+		result.Errors = []api.Message{{Text: stripANSI(err.Error())}}
+	}
+	return result
+}
+
+// Logs a failed build to w as JSON or to stderr and returns the exit code.
+// Every failure exits with exitCodeFailure, including failures after the
+// backend built, so failures are never mistaken for failed checks.
+func logBuildFailure(w io.Writer, err error, dur time.Duration, asJSON bool) int {
+	if asJSON {
+		if err := newBuildJSONFailure(err, dur).write(w); err != nil {
+			fmt.Fprintln(os.Stderr, format.Stderr(err))
+		}
+	} else if buildErr, ok := err.(buildError); ok {
+		buildErr.log()
+	} else {
+		fmt.Fprintln(os.Stderr, format.Stderr(err))
+	}
+	return exitCodeFailure
+}
+
+// Creates a result for a build that produced outputs
+func newBuildJSON(msg Message, dur time.Duration, rows []summaryRow, violations []budgetViolation, duplicates []duplicatePackage, strictDedupe bool) buildJSON {
+	result := buildJSON{
+		Status:     buildStatusSuccess,
+		ExitCode:   exitCodeSuccess,
+		DurationMs: dur.Milliseconds(),
+		Sourcemap:  RETRO_SOURCEMAP,
+		Errors:     msg.allErrors(),
+		Warnings:   msg.allWarnings(),
+	}
+	for _, row := range rows {
+		path, err := filepath.Rel(RETRO_OUT_DIR, row.path)
+		if err != nil {
+			path = row.path
+		}
+		result.Outputs = append(result.Outputs, buildJSONOutput{
+			Path:   filepath.ToSlash(path),
+			Bytes:  row.raw,
+			Gzip:   row.gzip,
+			Brotli: row.br,
+		})
+	}
+	for _, v := range violations {
+		result.Budgets = append(result.Budgets, buildJSONBudget{
+			Name:   v.name,
+			Metric: v.metric,
+			Bytes:  v.size,
+			Limit:  v.limit,
+			Hard:   v.hard,
+		})
+	}
+	for _, d := range duplicates {
+		duplicate := buildJSONDuplicate{Name: d.name, Wasted: d.wasted}
+		for _, copy := range d.copies {
+			duplicate.Copies = append(duplicate.Copies, buildJSONPackageCopy{
+				Dir:     copy.dir,
+				Version: copy.version,
+				Bytes:   copy.bytes,
+			})
+		}
+		result.Duplicates = append(result.Duplicates, duplicate)
+	}
+	if hasFailedChecks(violations, duplicates, strictDedupe) {
+		result.Status = buildStatusChecksFailed
+		result.ExitCode = exitCodeChecksFailed
+	}
+	return result
+}
+
+// Writes one JSON document. Nil slices are written as empty arrays.
+func (b buildJSON) write(w io.Writer) error {
+	if b.Errors == nil {
+		b.Errors = []api.Message{}
+	}
+	if b.Warnings == nil {
+		b.Warnings = []api.Message{}
+	}
+	if b.Outputs == nil {
+		b.Outputs = []buildJSONOutput{}
+	}
+	if b.Budgets == nil {
+		b.Budgets = []buildJSONBudget{}
+	}
+	if b.Duplicates == nil {
+		b.Duplicates = []buildJSONDuplicate{}
+	}
+	bstr, err := json.MarshalIndent(b, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(bstr, '\n'))
+	return err
+}
@@ -0,0 +1,75 @@
+package retro
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestIsBuildJSON(t *testing.T) {
+	expect.DeepEqual(t, isBuildJSON([]string{"build", "--json"}), true)
+	expect.DeepEqual(t, isBuildJSON([]string{"build", "--base=/", "--json=true"}), true)
+	expect.DeepEqual(t, isBuildJSON([]string{"build", "--json=false"}), false)
+	expect.DeepEqual(t, isBuildJSON([]string{"dev", "--json"}), false)
+	expect.DeepEqual(t, isBuildJSON(nil), false)
+}
+
+func TestNewBuildJSONFailure(t *testing.T) {
+	location := &api.Location{File: "src/index.js", Line: 1, Column: 2, LineText: "oops"}
+	msg := Message{ClientInfo: BundleInfo{
+		Errors:   []api.Message{{Text: "Expected \";\"", Location: location}},
+		Warnings: []api.Message{{Text: "Unused"}},
+	}}
+	result := newBuildJSONFailure(buildError{msg: msg}, 0)
+	expect.DeepEqual(t, result.Status, buildStatusFailure)
+	expect.DeepEqual(t, result.ExitCode, exitCodeFailure)
+	expect.DeepEqual(t, result.Errors, msg.ClientInfo.Errors)
+	expect.DeepEqual(t, result.Warnings, msg.ClientInfo.Warnings)
+
+	result = newBuildJSONFailure(buildError{stderr: "\x1b[31mTypeError\x1b[0m"}, 0)
+	expect.DeepEqual(t, result.Errors, []api.Message{{Text: "TypeError"}})
+
+	result = newBuildJSONFailure(errors.New("No such file or directory 'src/index.js'."), 0)
+	expect.DeepEqual(t, result.Errors, []api.Message{{Text: "No such file or directory 'src/index.js'."}})
+}
+
+func TestNewBuildJSON(t *testing.T) {
+	result := newBuildJSON(Message{}, 0, nil, []budgetViolation{{name: "total", metric: "raw", size: 2, limit: 1, hard: true}}, nil, false)
+	expect.DeepEqual(t, result.Status, buildStatusChecksFailed)
+	expect.DeepEqual(t, result.ExitCode, exitCodeChecksFailed)
+
+	result = newBuildJSON(Message{}, 0, nil, nil, []duplicatePackage{{name: "lodash"}}, false)
+	expect.DeepEqual(t, result.Status, buildStatusSuccess)
+
+	result = newBuildJSON(Message{}, 0, nil, nil, []duplicatePackage{{name: "lodash"}}, true)
+	expect.DeepEqual(t, result.Status, buildStatusChecksFailed)
+}
+
+func TestBuildJSONWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (buildJSON{Status: buildStatusSuccess}).write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	str := buf.String()
+	expect.DeepEqual(t, strings.Contains(str, `"errors": []`), true)
+	expect.DeepEqual(t, strings.Contains(str, `"outputs": []`), true)
+	expect.DeepEqual(t, strings.Contains(str, "\x1b"), false)
+}
+
+func TestLogBuildFailure(t *testing.T) {
+	var buf bytes.Buffer
+	code := logBuildFailure(&buf, errors.New("open out/retro-manifest.json: permission denied"), 0, true)
+	expect.DeepEqual(t, code, exitCodeFailure)
+	expect.NotDeepEqual(t, code, exitCodeChecksFailed)
+
+	var result buildJSON
+	must(json.Unmarshal(buf.Bytes(), &result))
+	expect.DeepEqual(t, result.Status, buildStatusFailure)
+	expect.DeepEqual(t, result.ExitCode, exitCodeFailure)
+	expect.DeepEqual(t, result.Errors[0].Text, "open out/retro-manifest.json: permission denied")
+}
@@ -0,0 +1,173 @@
+package retro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/zaydek/retro/go/cmd/format"
+	"github.com/zaydek/retro/go/cmd/retro/unix"
+	"github.com/zaydek/retro/go/pkg/ipc"
+	"github.com/zaydek/retro/go/pkg/manifest"
+	"github.com/zaydek/retro/go/pkg/terminal"
+	"github.com/zaydek/retro/go/pkg/watch"
+)
+
+// Removes outputs of the previous client bundle that the next client bundle no
+// longer writes, e.g. 'client__ABCD1234.js' after 'client__EFGH5678.js'
+func removeStaleOutputs(prev, next BundleInfo) error {
+	prevMeta, err := prev.getMetafile()
+	if err != nil {
+		return err
+	}
+	nextMeta, err := next.getMetafile()
+	if err != nil {
+		return err
+	}
+	for path := range prevMeta.Outputs {
+		if _, ok := nextMeta.Outputs[path]; ok {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Builds a one-line summary, e.g.
+//
+//   12:00:00  Built in 42ms  client__ABCD1234.js 12.3 KB  client__ABCD1234.css 1.2 KB
+//
+func buildWatchSummaryString(now time.Time, dur time.Duration, entries entryPoints, sizes map[string]int64, changed bool) string {
+	var parts []string
+	for _, entry := range []string{entries.vendorJS, entries.clientJS, entries.clientCSS} {
+		if entry == "" {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", entry, terminal.Dim(unix.HumanReadable(sizes[entry]))))
+	}
+	str := fmt.Sprintf("%s  %s  %s",
+		terminal.Dim(now.Format("15:04:05")),
+		terminal.Green(fmt.Sprintf("Built in %dms", dur.Milliseconds())),
+		strings.Join(parts, "  "),
+	)
+	if changed {
+		str += "  " + terminal.Cyan("(index.html updated)")
+	}
+	return str
+}
+
+// Gets output sizes relative to the output directory from the metafiles
+func getEntrySizes(msg Message) (map[string]int64, error) {
+	sizes := map[string]int64{}
+	for _, info := range []BundleInfo{msg.VendorInfo, msg.ClientInfo} {
+		meta, err := info.getMetafile()
+		if err != nil {
+			return nil, err
+		}
+		for path, output := range meta.Outputs {
+			rel, err := filepath.Rel(RETRO_OUT_DIR, path)
+			if err != nil {
+				return nil, err
+			}
+			sizes[rel] = output.Bytes
+		}
+	}
+	return sizes, nil
+}
+
+// Rebuilds the production build on changes to the source directory without a
+// server. The backend rebuilds the client bundle incrementally; the vendor
+// bundle is built once. Budgets are reported after every rebuild.
+func (a *App) buildWatch() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	stdin, stdout, stderr, err := ipc.NewPersistentCommand(ctx, "node", filepath.Join(__dirname, "scripts/backend.esbuild.js"))
+	if err != nil {
+		cancel()
+		return err
+	}
+	defer cancel()
+
+	tm := time.Now()
+	stdin <- "build"
+
+	var (
+		msg         Message
+		pageEntries []entryPoints
+		first       = true
+		events      = watch.Directory(RETRO_SRC_DIR, 100*time.Millisecond)
+	)
+	for {
+		select {
+		case line := <-stdout:
+			var next Message
+			if err := json.Unmarshal([]byte(line), &next); err != nil {
+				return err
+			}
+			dur := time.Since(tm)
+			// Rebuilds only describe the client bundle
+			if first {
+				msg = next
+				first = false
+			} else {
+				if err := removeStaleOutputs(msg.ClientInfo, next.ClientInfo); err != nil {
+					return err
+				}
+				msg.ClientInfo = next.ClientInfo
+			}
+			// Log to stdout and wait for changes
+			if msg.IsDirty() {
+				fmt.Println(msg.String())
+				continue
+			}
+			nextPageEntries := msg.getAllPageEntrypoints()
+			changed := !reflect.DeepEqual(nextPageEntries, pageEntries)
+			if changed {
+				if err := copyHTMLEntryPoints(msg); err != nil {
+					return err
+				}
+				pageEntries = nextPageEntries
+			}
+			entries := msg.getChunkedEntrypoints()
+			if RETRO_SOURCEMAP == "hidden" {
+				if err := hideSourcemaps(msg); err != nil {
+					return err
+				}
+			}
+			if err := writeManifest(manifest.ModeBuild, msg, dur); err != nil {
+				return err
+			}
+			sizes, err := getEntrySizes(msg)
+			if err != nil {
+				return err
+			}
+			fmt.Println(buildWatchSummaryString(time.Now(), dur, entries, sizes, changed))
+			violations, err := checkBudgets(msg)
+			if err != nil {
+				return err
+			}
+			for _, v := range violations {
+				fmt.Println(terminal.Red("Over budget: ") + v.String())
+			}
+			if msg.HasWarnings() {
+				fmt.Println(msg.String())
+			}
+		case text := <-stderr:
+			fmt.Fprintln(os.Stderr, format.StderrIPC(text))
+			cancel()
+			os.Exit(1)
+		case event := <-events:
+			if event.Err != nil {
+				return event.Err
+			}
+			tm = time.Now() // Reset
+			stdin <- "rebuild"
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package retro
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestRemoveStaleOutputs(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		prevJS   = filepath.Join(dir, "client__AAAAAAAA.js")
+		prevCSS  = filepath.Join(dir, "client__AAAAAAAA.css")
+		nextJS   = filepath.Join(dir, "client__BBBBBBBB.js")
+		missing  = filepath.Join(dir, "client__CCCCCCCC.js")
+		outputOf = func(paths ...string) BundleInfo {
+			outputs := map[string]interface{}{}
+			for _, path := range paths {
+				outputs[path] = map[string]interface{}{}
+			}
+			return BundleInfo{Metafile: map[string]interface{}{"outputs": outputs}}
+		}
+	)
+	for _, path := range []string{prevJS, prevCSS, nextJS} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := removeStaleOutputs(outputOf(prevJS, prevCSS, missing), outputOf(nextJS, prevCSS)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := os.Stat(prevJS)
+	expect.DeepEqual(t, os.IsNotExist(err), true)
+	_, err = os.Stat(prevCSS)
+	expect.DeepEqual(t, err, nil)
+	_, err = os.Stat(nextJS)
+	expect.DeepEqual(t, err, nil)
+}
+
+func TestBuildWatchSummaryString(t *testing.T) {
+	entries := entryPoints{clientCSS: "client__A.css", vendorJS: "vendor__A.js", clientJS: "client__A.js"}
+	sizes := map[string]int64{"client__A.css": 100, "vendor__A.js": 2048, "client__A.js": 300}
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	str := stripANSI(buildWatchSummaryString(now, 42*time.Millisecond, entries, sizes, false))
+	expect.DeepEqual(t, str, "12:00:00  Built in 42ms  vendor__A.js 2.0 KB  client__A.js 300 B  client__A.css 100 B")
+
+	str = stripANSI(buildWatchSummaryString(now, 42*time.Millisecond, entries, sizes, true))
+	expect.DeepEqual(t, strings.HasSuffix(str, "(index.html updated)"), true)
+}
+
+func TestHideSourcemapsOnRebuild(t *testing.T) {
+	defer func(outDir string) { RETRO_OUT_DIR = outDir }(RETRO_OUT_DIR)
+	RETRO_OUT_DIR = filepath.Join(t.TempDir(), "out")
+	must(os.MkdirAll(RETRO_OUT_DIR, 0755))
+
+	var (
+		vendorMap = filepath.Join(RETRO_OUT_DIR, "vendor__AAAAAAAA.js.map")
+		prevMap   = filepath.Join(RETRO_OUT_DIR, "client__BBBBBBBB.js.map")
+		nextMap   = filepath.Join(RETRO_OUT_DIR, "client__CCCCCCCC.js.map")
+		outputOf  = func(paths ...string) BundleInfo {
+			outputs := map[string]interface{}{}
+			for _, path := range paths {
+				outputs[path] = map[string]interface{}{}
+			}
+			return BundleInfo{Metafile: map[string]interface{}{"outputs": outputs}}
+		}
+		hidden = func(path string) string {
+			return filepath.Join(getHiddenSourcemapDir(), filepath.Base(path))
+		}
+	)
+
+	// The first build
+	for _, path := range []string{vendorMap, prevMap} {
+		must(os.WriteFile(path, nil, 0644))
+	}
+	msg := Message{VendorInfo: outputOf(vendorMap), ClientInfo: outputOf(prevMap)}
+	must(hideSourcemaps(msg))
+
+	// Rebuilds keep the vendor bundle, whose sourcemaps were already moved
+	must(os.WriteFile(nextMap, nil, 0644))
+	next := outputOf(nextMap)
+	must(removeStaleOutputs(msg.ClientInfo, next))
+	msg.ClientInfo = next
+	must(hideSourcemaps(msg))
+
+	for _, path := range []string{vendorMap, prevMap, nextMap} {
+		_, err := os.Stat(path)
+		expect.DeepEqual(t, os.IsNotExist(err), true)
+	}
+	for _, path := range []string{vendorMap, nextMap} {
+		_, err := os.Stat(hidden(path))
+		expect.DeepEqual(t, err, nil)
+	}
+}
@@ -0,0 +1,65 @@
+package retro
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/zaydek/retro/go/pkg/manifest"
+)
+
+// The content-hashed outputs of the served build, relative to the output
+// directory. Set from the manifest when 'retro serve' starts.
+var hashedOutputs = map[string]bool{}
+
+// Gets the content-hashed outputs of a build. Only esbuild outputs are hashed;
+// files copied from the static directory never are, whatever their names.
+func getHashedOutputs(m manifest.Manifest) map[string]bool {
+	out := map[string]bool{}
+	for path := range m.Outputs {
+		if getLogicalFilename(path) != path {
+			out[path] = true
+		}
+	}
+	return out
+}
+
+// Checks whether filename is a content-hashed output of the served build
+func isHashedFilename(filename string) bool {
+	rel, err := filepath.Rel(RETRO_OUT_DIR, filename)
+	if err != nil {
+		return false
+	}
+	return hashedOutputs[filepath.ToSlash(rel)]
+}
+
+type etagKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Caches ETags so files are only hashed once per modification
+var etagCache sync.Map // map[etagKey]string
+
+// Gets a strong ETag for the contents of file
+func getETag(file *os.File, info os.FileInfo) (string, error) {
+	key := etagKey{path: file.Name(), size: info.Size(), modTime: info.ModTime()}
+	if etag, ok := etagCache.Load(key); ok {
+		return etag.(string), nil
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	etagCache.Store(key, etag)
+	return etag, nil
+}
@@ -0,0 +1,101 @@
+package retro
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+	"github.com/zaydek/retro/go/pkg/manifest"
+)
+
+func TestGetHashedOutputs(t *testing.T) {
+	m := manifest.Manifest{Outputs: map[string]manifest.Output{
+		"vendor__ABCD1234.js":         {Kind: manifest.KindVendorJS},
+		"client__EFGH5678.js.map":     {Kind: manifest.KindSourcemap},
+		"pages/pricing__IJKL9012.css": {Kind: manifest.KindClientCSS},
+		"chunk-MNOP3456.js":           {Kind: manifest.KindChunk},
+		"logo.svg":                    {Kind: manifest.KindAsset},
+	}}
+	expect.DeepEqual(t, getHashedOutputs(m), map[string]bool{
+		"vendor__ABCD1234.js":         true,
+		"client__EFGH5678.js.map":     true,
+		"pages/pricing__IJKL9012.css": true,
+		"chunk-MNOP3456.js":           true,
+	})
+}
+
+func TestServeFileCacheControl(t *testing.T) {
+	prevOut, prevCmd, prevHashed, prevConfig := RETRO_OUT_DIR, RETRO_CMD, hashedOutputs, userConfig
+	t.Cleanup(func() { RETRO_OUT_DIR, RETRO_CMD, hashedOutputs, userConfig = prevOut, prevCmd, prevHashed, prevConfig })
+	RETRO_OUT_DIR, RETRO_CMD = t.TempDir(), string(KindServeCommand)
+	userConfig.Serve.CacheControl = CacheControlConfig{Hashed: "immutable", Unhashed: "no-cache"}
+
+	var (
+		hashed   = filepath.Join(RETRO_OUT_DIR, "client__ABCD1234.js")
+		unhashed = filepath.Join(RETRO_OUT_DIR, "index.html")
+		// Named like a hashed output but copied from the static directory
+		lookalike = filepath.Join(RETRO_OUT_DIR, "www", "font__WXYZ0000.woff2")
+	)
+	must(os.MkdirAll(filepath.Dir(lookalike), 0755))
+	for _, filename := range []string{hashed, unhashed, lookalike} {
+		must(os.WriteFile(filename, []byte(filepath.Base(filename)), 0644))
+	}
+	hashedOutputs = getHashedOutputs(manifest.Manifest{Outputs: map[string]manifest.Output{
+		"client__ABCD1234.js": {Kind: manifest.KindClientJS},
+	}})
+
+	get := func(filename, ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/"+filepath.Base(filename), nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		serveFile(w, r, filename)
+		return w
+	}
+
+	w := get(hashed, "")
+	expect.DeepEqual(t, w.Header().Get("Cache-Control"), "immutable")
+	expect.DeepEqual(t, w.Header().Get("ETag"), "")
+
+	for _, filename := range []string{unhashed, lookalike} {
+		w = get(filename, "")
+		expect.DeepEqual(t, w.Code, http.StatusOK)
+		expect.DeepEqual(t, w.Header().Get("Cache-Control"), "no-cache")
+		etag := w.Header().Get("ETag")
+		expect.NotDeepEqual(t, etag, "")
+
+		// Revalidates with the ETag
+		w = get(filename, etag)
+		expect.DeepEqual(t, w.Code, http.StatusNotModified)
+		expect.DeepEqual(t, w.Body.String(), "")
+		w = get(filename, `"stale"`)
+		expect.DeepEqual(t, w.Code, http.StatusOK)
+	}
+}
+
+func TestServeFileCacheControlHeadersFile(t *testing.T) {
+	prevOut, prevCmd, prevHashed, prevConfig := RETRO_OUT_DIR, RETRO_CMD, hashedOutputs, userConfig
+	t.Cleanup(func() { RETRO_OUT_DIR, RETRO_CMD, hashedOutputs, userConfig = prevOut, prevCmd, prevHashed, prevConfig })
+	RETRO_OUT_DIR, RETRO_CMD = t.TempDir(), string(KindServeCommand)
+	userConfig.Serve.CacheControl = CacheControlConfig{Hashed: "immutable", Unhashed: "no-cache"}
+	hashedOutputs = map[string]bool{"client__ABCD1234.js": true}
+
+	rules, err := parseHeadersFile("_headers", `
+/*
+  Cache-Control: max-age=60
+`)
+	must(err)
+	for _, name := range []string{"index.html", "client__ABCD1234.js"} {
+		filename := filepath.Join(RETRO_OUT_DIR, name)
+		must(os.WriteFile(filename, []byte(name), 0644))
+
+		w := httptest.NewRecorder()
+		applyHeaderRules(w, rules, "/"+name)
+		serveFile(w, httptest.NewRequest("GET", "/"+name, nil), filename)
+		expect.DeepEqual(t, w.Header().Values("Cache-Control"), []string{"max-age=60"})
+	}
+}
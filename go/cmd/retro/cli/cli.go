@@ -23,6 +23,20 @@ const (
 	BadPortValue
 	BadSourcemapValue
 	BadPortRange
+	BadPrecompressValue
+	BadBaseValue
+	BadReportValue
+	BadStrictDedupeValue
+	BadDiffValue
+	BadJSONValue
+	BadWatchValue
+	BadWatchAndJSON
+	BadWatchAndArgument
+	BadWarningsAsErrorsValue
+	BadSrcValue
+	BadWWWValue
+	BadOutValue
+	BadForceValue
 )
 
 type CommandError struct {
@@ -44,9 +58,37 @@ func (e CommandError) Error() string {
 	case BadPortValue:
 		return "'--port' must be a number (default '8000')."
 	case BadSourcemapValue:
-		return "'--sourcemap' must be a 'true' or 'false' or empty (default 'true')."
+		return "'--sourcemap' must be one of 'none', 'linked', 'external', 'inline', or 'hidden' (default 'linked')."
 	case BadPortRange:
 		return fmt.Sprintf("'--port' must be between '1000' and '10000'; used '%d'.", e.BadPort)
+	case BadPrecompressValue:
+		return "'--precompress' must be a 'true' or 'false' or empty (default 'false')."
+	case BadBaseValue:
+		return "'--base' must be a path, for example '--base=/app-name/' (default '/')."
+	case BadReportValue:
+		return "'--report' must be a filename, for example '--report=report.html' (default 'out/retro-analyze.html')."
+	case BadStrictDedupeValue:
+		return "'--strict-dedupe' must be a 'true' or 'false' or empty (default 'false')."
+	case BadJSONValue:
+		return "'--json' must be a 'true' or 'false' or empty (default 'false')."
+	case BadWatchValue:
+		return "'--watch' must be a 'true' or 'false' or empty (default 'false')."
+	case BadWatchAndJSON:
+		return "'--watch' and '--json' cannot be used together."
+	case BadWatchAndArgument:
+		return fmt.Sprintf("'--watch' and '%s' cannot be used together; '%s' only applies to one-off builds.", e.BadArgument, e.BadArgument)
+	case BadWarningsAsErrorsValue:
+		return "'--warnings-as-errors' must be a 'true' or 'false' or empty (default 'false')."
+	case BadSrcValue:
+		return "'--src' must be a directory, for example '--src=app' (default 'src')."
+	case BadWWWValue:
+		return "'--www' must be a directory, for example '--www=public' (default 'www')."
+	case BadOutValue:
+		return "'--out' must be a directory, for example '--out=dist' (default 'out')."
+	case BadForceValue:
+		return "'--force' must be a 'true' or 'false' or empty (default 'false')."
+	case BadDiffValue:
+		return "'--diff' must be a filename, for example '--diff=main/retro-manifest.json' (default the previous build)."
 	}
 	panic("Internal error")
 }
@@ -58,10 +100,55 @@ func (e CommandError) Unwrap() error {
 // Support _ separators
 var portRegex = regexp.MustCompile(`^--port=([\d_]+)$`)
 
+var baseRegex = regexp.MustCompile(`^--base=(\S+)$`)
+
+func parseBase(arg string) (string, bool) {
+	matches := baseRegex.FindStringSubmatch(arg)
+	if len(matches) != 2 {
+		return "", false
+	}
+	return matches[1], true
+}
+
+var dirRegex = regexp.MustCompile(`^--(?:src|www|out)=(\S+)$`)
+
+// Parses '--src=dir', '--www=dir', or '--out=dir'
+func parseDir(arg string) (string, bool) {
+	matches := dirRegex.FindStringSubmatch(arg)
+	if len(matches) != 2 {
+		return "", false
+	}
+	return matches[1], true
+}
+
+// Sourcemap modes; an empty mode uses the environment or 'linked'
+var sourcemapModes = map[string]bool{
+	"none":     true,
+	"linked":   true,
+	"external": true,
+	"inline":   true,
+	"hidden":   true,
+}
+
+// Parses '--sourcemap=mode'. '--sourcemap' and '--sourcemap=true' are 'linked'
+// and '--sourcemap=false' is 'none'.
+func parseSourcemap(arg string) (string, bool) {
+	switch arg {
+	case "--sourcemap", "--sourcemap=true":
+		return "linked", true
+	case "--sourcemap=false":
+		return "none", true
+	}
+	mode := strings.TrimPrefix(arg, "--sourcemap=")
+	if mode == arg || !sourcemapModes[mode] {
+		return "", false
+	}
+	return mode, true
+}
+
 func ParseDevCommand(args ...string) (DevCommand, error) {
 	command := DevCommand{
-		Sourcemap: true,
-		Port:      8000,
+		Port: 8000,
 	}
 	for _, arg := range args {
 		err := CommandError{Kind: BadArgument, BadArgument: arg}
@@ -74,14 +161,53 @@ func ParseDevCommand(args ...string) (DevCommand, error) {
 				return DevCommand{}, err
 			}
 		} else if strings.HasPrefix(arg, "--sourcemap") {
-			if arg == "--sourcemap" {
-				command.Sourcemap = true
-			} else if arg == "--sourcemap=true" || arg == "--sourcemap=false" {
-				command.Sourcemap = arg == "--sourcemap=true"
-			} else {
+			var ok bool
+			if command.Sourcemap, ok = parseSourcemap(arg); !ok {
 				err.Kind = BadSourcemapValue
 				return DevCommand{}, err
 			}
+		} else if strings.HasPrefix(arg, "--base") {
+			var ok bool
+			if command.Base, ok = parseBase(arg); !ok {
+				err.Kind = BadBaseValue
+				return DevCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--src") {
+			var ok bool
+			if command.SrcDir, ok = parseDir(arg); !ok {
+				err.Kind = BadSrcValue
+				return DevCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--www") {
+			var ok bool
+			if command.WWWDir, ok = parseDir(arg); !ok {
+				err.Kind = BadWWWValue
+				return DevCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--out") {
+			var ok bool
+			if command.OutDir, ok = parseDir(arg); !ok {
+				err.Kind = BadOutValue
+				return DevCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--warnings-as-errors") {
+			if arg == "--warnings-as-errors" {
+				command.WarningsAsErrors = true
+			} else if arg == "--warnings-as-errors=true" || arg == "--warnings-as-errors=false" {
+				command.WarningsAsErrors = arg == "--warnings-as-errors=true"
+			} else {
+				err.Kind = BadWarningsAsErrorsValue
+				return DevCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--force") {
+			if arg == "--force" {
+				command.Force = true
+			} else if arg == "--force=true" || arg == "--force=false" {
+				command.Force = arg == "--force=true"
+			} else {
+				err.Kind = BadForceValue
+				return DevCommand{}, err
+			}
 		} else {
 			return DevCommand{}, err
 		}
@@ -92,25 +218,125 @@ func ParseDevCommand(args ...string) (DevCommand, error) {
 	return command, nil
 }
 
+var diffRegex = regexp.MustCompile(`^--diff=(\S+)$`)
+
 func ParseBuildCommand(args ...string) (BuildCommand, error) {
-	command := BuildCommand{
-		Sourcemap: true,
-	}
+	var command BuildCommand
 	for _, arg := range args {
 		err := CommandError{Kind: BadArgument, BadArgument: arg}
 		if strings.HasPrefix(arg, "--sourcemap") {
-			if arg == "--sourcemap" {
-				command.Sourcemap = true
-			} else if arg == "--sourcemap=true" || arg == "--sourcemap=false" {
-				command.Sourcemap = arg == "--sourcemap=true"
-			} else {
+			var ok bool
+			if command.Sourcemap, ok = parseSourcemap(arg); !ok {
 				err.Kind = BadSourcemapValue
 				return BuildCommand{}, err
 			}
+		} else if strings.HasPrefix(arg, "--precompress") {
+			if arg == "--precompress" {
+				command.Precompress = true
+			} else if arg == "--precompress=true" || arg == "--precompress=false" {
+				command.Precompress = arg == "--precompress=true"
+			} else {
+				err.Kind = BadPrecompressValue
+				return BuildCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--strict-dedupe") {
+			if arg == "--strict-dedupe" {
+				command.StrictDedupe = true
+			} else if arg == "--strict-dedupe=true" || arg == "--strict-dedupe=false" {
+				command.StrictDedupe = arg == "--strict-dedupe=true"
+			} else {
+				err.Kind = BadStrictDedupeValue
+				return BuildCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--json") {
+			if arg == "--json" {
+				command.JSON = true
+			} else if arg == "--json=true" || arg == "--json=false" {
+				command.JSON = arg == "--json=true"
+			} else {
+				err.Kind = BadJSONValue
+				return BuildCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--watch") {
+			if arg == "--watch" {
+				command.Watch = true
+			} else if arg == "--watch=true" || arg == "--watch=false" {
+				command.Watch = arg == "--watch=true"
+			} else {
+				err.Kind = BadWatchValue
+				return BuildCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--warnings-as-errors") {
+			if arg == "--warnings-as-errors" {
+				command.WarningsAsErrors = true
+			} else if arg == "--warnings-as-errors=true" || arg == "--warnings-as-errors=false" {
+				command.WarningsAsErrors = arg == "--warnings-as-errors=true"
+			} else {
+				err.Kind = BadWarningsAsErrorsValue
+				return BuildCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--force") {
+			if arg == "--force" {
+				command.Force = true
+			} else if arg == "--force=true" || arg == "--force=false" {
+				command.Force = arg == "--force=true"
+			} else {
+				err.Kind = BadForceValue
+				return BuildCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--diff") {
+			matches := diffRegex.FindStringSubmatch(arg)
+			if len(matches) == 2 {
+				command.Diff = matches[1]
+			} else {
+				err.Kind = BadDiffValue
+				return BuildCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--base") {
+			var ok bool
+			if command.Base, ok = parseBase(arg); !ok {
+				err.Kind = BadBaseValue
+				return BuildCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--src") {
+			var ok bool
+			if command.SrcDir, ok = parseDir(arg); !ok {
+				err.Kind = BadSrcValue
+				return BuildCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--www") {
+			var ok bool
+			if command.WWWDir, ok = parseDir(arg); !ok {
+				err.Kind = BadWWWValue
+				return BuildCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--out") {
+			var ok bool
+			if command.OutDir, ok = parseDir(arg); !ok {
+				err.Kind = BadOutValue
+				return BuildCommand{}, err
+			}
 		} else {
 			return BuildCommand{}, err
 		}
 	}
+	if command.Watch && command.JSON {
+		return BuildCommand{}, CommandError{Kind: BadWatchAndJSON}
+	}
+	if command.Watch {
+		for _, pair := range []struct {
+			set bool
+			arg string
+		}{
+			{set: command.Precompress, arg: "--precompress"},
+			{set: command.StrictDedupe, arg: "--strict-dedupe"},
+			{set: command.Diff != "", arg: "--diff"},
+		} {
+			if pair.set {
+				return BuildCommand{}, CommandError{Kind: BadWatchAndArgument, BadArgument: pair.arg}
+			}
+		}
+	}
 	return command, nil
 }
 
@@ -128,6 +354,30 @@ func ParseServeCommand(args ...string) (ServeCommand, error) {
 				err.Kind = BadPortValue
 				return ServeCommand{}, err
 			}
+		} else if strings.HasPrefix(arg, "--base") {
+			var ok bool
+			if command.Base, ok = parseBase(arg); !ok {
+				err.Kind = BadBaseValue
+				return ServeCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--src") {
+			var ok bool
+			if command.SrcDir, ok = parseDir(arg); !ok {
+				err.Kind = BadSrcValue
+				return ServeCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--www") {
+			var ok bool
+			if command.WWWDir, ok = parseDir(arg); !ok {
+				err.Kind = BadWWWValue
+				return ServeCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--out") {
+			var ok bool
+			if command.OutDir, ok = parseDir(arg); !ok {
+				err.Kind = BadOutValue
+				return ServeCommand{}, err
+			}
 		} else {
 			return ServeCommand{}, err
 		}
@@ -138,6 +388,36 @@ func ParseServeCommand(args ...string) (ServeCommand, error) {
 	return command, nil
 }
 
+var reportRegex = regexp.MustCompile(`^--report=(\S+)$`)
+
+func ParseAnalyzeCommand(args ...string) (AnalyzeCommand, error) {
+	var command AnalyzeCommand
+	for _, arg := range args {
+		err := CommandError{Kind: BadArgument, BadArgument: arg}
+		if strings.HasPrefix(arg, "--report") {
+			matches := reportRegex.FindStringSubmatch(arg)
+			if len(matches) == 2 {
+				command.Report = matches[1]
+			} else {
+				err.Kind = BadReportValue
+				return AnalyzeCommand{}, err
+			}
+		} else if strings.HasPrefix(arg, "--force") {
+			if arg == "--force" {
+				command.Force = true
+			} else if arg == "--force=true" || arg == "--force=false" {
+				command.Force = arg == "--force=true"
+			} else {
+				err.Kind = BadForceValue
+				return AnalyzeCommand{}, err
+			}
+		} else {
+			return AnalyzeCommand{}, err
+		}
+	}
+	return command, nil
+}
+
 func ParseCLIArguments() (interface{}, error) {
 	if len(os.Args) < 2 {
 		return nil, ErrUsage
@@ -160,6 +440,8 @@ func ParseCLIArguments() (interface{}, error) {
 		command, err = ParseBuildCommand(os.Args[2:]...)
 	} else if cmdArg == "serve" {
 		command, err = ParseServeCommand(os.Args[2:]...)
+	} else if cmdArg == "analyze" {
+		command, err = ParseAnalyzeCommand(os.Args[2:]...)
 	} else {
 		err = CommandError{Kind: BadCommandArgument, BadCmdArgument: cmdArg}
 	}
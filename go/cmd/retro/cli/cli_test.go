@@ -22,44 +22,83 @@ func TestDevCommand(t *testing.T) {
 	command, err = ParseDevCommand()
 	must(t, err)
 	expect.DeepEqual(t, command, DevCommand{
-		Port:      8000,
-		Sourcemap: true,
+		Port: 8000,
 	})
 
 	command, err = ParseDevCommand("--port=8000")
 	must(t, err)
 	expect.DeepEqual(t, command, DevCommand{
-		Port:      8000,
-		Sourcemap: true,
+		Port: 8000,
 	})
 
 	command, err = ParseDevCommand("--port=3000")
 	must(t, err)
 	expect.DeepEqual(t, command, DevCommand{
-		Port:      3000,
-		Sourcemap: true,
+		Port: 3000,
 	})
 
 	command, err = ParseDevCommand("--sourcemap")
 	must(t, err)
 	expect.DeepEqual(t, command, DevCommand{
 		Port:      8000,
-		Sourcemap: true,
+		Sourcemap: "linked",
 	})
 
 	command, err = ParseDevCommand("--sourcemap=true")
 	must(t, err)
 	expect.DeepEqual(t, command, DevCommand{
 		Port:      8000,
-		Sourcemap: true,
+		Sourcemap: "linked",
 	})
 
 	command, err = ParseDevCommand("--sourcemap=false")
 	must(t, err)
 	expect.DeepEqual(t, command, DevCommand{
 		Port:      8000,
-		Sourcemap: false,
+		Sourcemap: "none",
+	})
+
+	command, err = ParseDevCommand("--base=/app-name/")
+	must(t, err)
+	expect.DeepEqual(t, command, DevCommand{
+		Port: 8000,
+		Base: "/app-name/",
+	})
+
+	_, err = ParseDevCommand("--base")
+	expect.DeepEqual(t, err, CommandError{Kind: BadBaseValue, BadArgument: "--base"})
+
+	command, err = ParseDevCommand("--warnings-as-errors")
+	must(t, err)
+	expect.DeepEqual(t, command, DevCommand{
+		Port:             8000,
+		WarningsAsErrors: true,
+	})
+
+	command, err = ParseDevCommand("--src=app", "--www=public", "--out=dist")
+	must(t, err)
+	expect.DeepEqual(t, command, DevCommand{
+		Port:   8000,
+		SrcDir: "app",
+		WWWDir: "public",
+		OutDir: "dist",
+	})
+
+	_, err = ParseDevCommand("--src")
+	expect.DeepEqual(t, err, CommandError{Kind: BadSrcValue, BadArgument: "--src"})
+
+	_, err = ParseDevCommand("--warnings-as-errors=yes")
+	expect.DeepEqual(t, err, CommandError{Kind: BadWarningsAsErrorsValue, BadArgument: "--warnings-as-errors=yes"})
+
+	command, err = ParseDevCommand("--force")
+	must(t, err)
+	expect.DeepEqual(t, command, DevCommand{
+		Port:  8000,
+		Force: true,
 	})
+
+	_, err = ParseDevCommand("--force=yes")
+	expect.DeepEqual(t, err, CommandError{Kind: BadForceValue, BadArgument: "--force=yes"})
 }
 
 func TestBuildCommand(t *testing.T) {
@@ -70,26 +109,124 @@ func TestBuildCommand(t *testing.T) {
 
 	command, err = ParseBuildCommand()
 	must(t, err)
-	expect.DeepEqual(t, command, BuildCommand{
-		Sourcemap: true,
-	})
+	expect.DeepEqual(t, command, BuildCommand{})
 
 	command, err = ParseBuildCommand("--sourcemap")
 	must(t, err)
 	expect.DeepEqual(t, command, BuildCommand{
-		Sourcemap: true,
+		Sourcemap: "linked",
 	})
 
 	command, err = ParseBuildCommand("--sourcemap=true")
 	must(t, err)
 	expect.DeepEqual(t, command, BuildCommand{
-		Sourcemap: true,
+		Sourcemap: "linked",
 	})
 
 	command, err = ParseBuildCommand("--sourcemap=false")
 	must(t, err)
 	expect.DeepEqual(t, command, BuildCommand{
-		Sourcemap: false,
+		Sourcemap: "none",
+	})
+
+	command, err = ParseBuildCommand("--sourcemap=hidden")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		Sourcemap: "hidden",
+	})
+
+	command, err = ParseBuildCommand("--sourcemap=inline")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		Sourcemap: "inline",
+	})
+
+	_, err = ParseBuildCommand("--sourcemap=both")
+	expect.DeepEqual(t, err, CommandError{Kind: BadSourcemapValue, BadArgument: "--sourcemap=both"})
+
+	command, err = ParseBuildCommand("--precompress")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		Precompress: true,
+	})
+
+	command, err = ParseBuildCommand("--precompress=false")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		Precompress: false,
+	})
+
+	command, err = ParseBuildCommand("--strict-dedupe")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		StrictDedupe: true,
+	})
+
+	_, err = ParseBuildCommand("--strict-dedupe=yes")
+	expect.DeepEqual(t, err, CommandError{Kind: BadStrictDedupeValue, BadArgument: "--strict-dedupe=yes"})
+
+	command, err = ParseBuildCommand("--diff=main/retro-manifest.json")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		Diff: "main/retro-manifest.json",
+	})
+
+	_, err = ParseBuildCommand("--diff")
+	expect.DeepEqual(t, err, CommandError{Kind: BadDiffValue, BadArgument: "--diff"})
+
+	command, err = ParseBuildCommand("--json")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		JSON: true,
+	})
+
+	command, err = ParseBuildCommand("--watch")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		Watch: true,
+	})
+
+	_, err = ParseBuildCommand("--watch", "--json")
+	expect.DeepEqual(t, err, CommandError{Kind: BadWatchAndJSON})
+
+	_, err = ParseBuildCommand("--watch", "--precompress")
+	expect.DeepEqual(t, err, CommandError{Kind: BadWatchAndArgument, BadArgument: "--precompress"})
+
+	_, err = ParseBuildCommand("--strict-dedupe", "--watch")
+	expect.DeepEqual(t, err, CommandError{Kind: BadWatchAndArgument, BadArgument: "--strict-dedupe"})
+
+	_, err = ParseBuildCommand("--watch", "--diff=main/retro-manifest.json")
+	expect.DeepEqual(t, err, CommandError{Kind: BadWatchAndArgument, BadArgument: "--diff"})
+
+	command, err = ParseBuildCommand("--watch", "--precompress=false")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		Watch: true,
+	})
+
+	command, err = ParseBuildCommand("--warnings-as-errors")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		WarningsAsErrors: true,
+	})
+
+	command, err = ParseBuildCommand("--force=false")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{})
+
+	command, err = ParseBuildCommand("--out=dist")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		OutDir: "dist",
+	})
+
+	_, err = ParseBuildCommand("--www")
+	expect.DeepEqual(t, err, CommandError{Kind: BadWWWValue, BadArgument: "--www"})
+
+	command, err = ParseBuildCommand("--base=/app-name/")
+	must(t, err)
+	expect.DeepEqual(t, command, BuildCommand{
+		Base: "/app-name/",
 	})
 }
 
@@ -116,4 +253,47 @@ func TestServeCommand(t *testing.T) {
 	expect.DeepEqual(t, command, ServeCommand{
 		Port: 3000,
 	})
+
+	command, err = ParseServeCommand("--base=/app-name/")
+	must(t, err)
+	expect.DeepEqual(t, command, ServeCommand{
+		Port: 8000,
+		Base: "/app-name/",
+	})
+
+	command, err = ParseServeCommand("--out=dist")
+	must(t, err)
+	expect.DeepEqual(t, command, ServeCommand{
+		Port:   8000,
+		OutDir: "dist",
+	})
+
+	_, err = ParseServeCommand("--out=")
+	expect.DeepEqual(t, err, CommandError{Kind: BadOutValue, BadArgument: "--out="})
+}
+
+func TestAnalyzeCommand(t *testing.T) {
+	var (
+		command AnalyzeCommand
+		err     error
+	)
+
+	command, err = ParseAnalyzeCommand()
+	must(t, err)
+	expect.DeepEqual(t, command, AnalyzeCommand{})
+
+	command, err = ParseAnalyzeCommand("--report=report.html")
+	must(t, err)
+	expect.DeepEqual(t, command, AnalyzeCommand{
+		Report: "report.html",
+	})
+
+	_, err = ParseAnalyzeCommand("--report")
+	expect.DeepEqual(t, err, CommandError{Kind: BadReportValue, BadArgument: "--report"})
+
+	command, err = ParseAnalyzeCommand("--force=true")
+	must(t, err)
+	expect.DeepEqual(t, command, AnalyzeCommand{
+		Force: true,
+	})
 }
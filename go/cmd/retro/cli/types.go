@@ -2,16 +2,43 @@ package cli
 
 // Describes the dev command
 type DevCommand struct {
-	Port      int
-	Sourcemap bool
+	Port             int
+	Sourcemap        string // One of "none", "linked", "external", "inline", or "hidden"
+	WarningsAsErrors bool
+	Force            bool // Allows deleting an output directory outside of the project
+	SrcDir           string
+	WWWDir           string
+	OutDir           string
+	Base             string
 }
 
 // Describes the build command
 type BuildCommand struct {
-	Sourcemap bool
+	Sourcemap        string // One of "none", "linked", "external", "inline", or "hidden"
+	Precompress      bool
+	StrictDedupe     bool
+	Diff             string // Compares sizes to this manifest; empty uses the previous build
+	JSON             bool
+	Watch            bool
+	WarningsAsErrors bool
+	Force            bool // Allows deleting an output directory outside of the project
+	SrcDir           string
+	WWWDir           string
+	OutDir           string
+	Base             string
 }
 
 // Describes the serve command
 type ServeCommand struct {
-	Port int
+	Port   int
+	SrcDir string
+	WWWDir string
+	OutDir string
+	Base   string
+}
+
+// Describes the analyze command
+type AnalyzeCommand struct {
+	Report string
+	Force  bool // Allows deleting an output directory outside of the project
 }
@@ -0,0 +1,223 @@
+package retro
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Files smaller than this are not worth compressing
+const precompressMinSize = 1024
+
+// Extensions that are precompressed by the build command
+var precompressExts = map[string]bool{
+	".css":  true,
+	".html": true,
+	".js":   true,
+	".svg":  true,
+}
+
+type encodingVariant struct {
+	encoding string // The Content-Encoding value
+	ext      string // The filename suffix
+}
+
+// Ordered by preference
+var encodingVariants = []encodingVariant{
+	{encoding: "br", ext: ".br"},
+	{encoding: "gzip", ext: ".gz"},
+}
+
+func isEncodingVariant(path string) bool {
+	for _, variant := range encodingVariants {
+		if strings.HasSuffix(path, variant.ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeGzip(w io.Writer, r io.Reader) error {
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(gz, r); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeBrotli(w io.Writer, r io.Reader) error {
+	br := brotli.NewWriterLevel(w, brotli.BestCompression)
+	if _, err := io.Copy(br, r); err != nil {
+		return err
+	}
+	return br.Close()
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	w.n += int64(len(b))
+	return len(b), nil
+}
+
+// Gets the gzipped size of a file without writing it
+func gzipSize(filename string) (int64, error) {
+	src, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	var w countingWriter
+	if err := writeGzip(&w, src); err != nil {
+		return 0, err
+	}
+	return w.n, nil
+}
+
+func precompressFile(filename string, variant encodingVariant) error {
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(filename + variant.ext)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	switch variant.encoding {
+	case "br":
+		err = writeBrotli(dst, src)
+	case "gzip":
+		err = writeGzip(dst, src)
+	}
+	return err
+}
+
+// Writes '.gz' and '.br' files next to every compressible file in dir
+func precompressDirectory(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !precompressExts[filepath.Ext(path)] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() < precompressMinSize {
+			return nil
+		}
+		for _, variant := range encodingVariants {
+			if err := precompressFile(path, variant); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Parses an Accept-Encoding header for whether encoding is acceptable. Note that
+// 'q=0' explicitly rejects an encoding.
+func acceptsEncoding(header, encoding string) bool {
+	var wildcard bool
+	for _, part := range strings.Split(header, ",") {
+		var (
+			name = part
+			q    = 1.0
+		)
+		if index := strings.Index(part, ";"); index >= 0 {
+			name = part[:index]
+			param := strings.TrimSpace(part[index+1:])
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[len("q="):], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == encoding {
+			return q > 0
+		} else if name == "*" {
+			wildcard = q > 0
+		}
+	}
+	return wildcard
+}
+
+// Serves filename, preferring a precompressed variant when the client accepts
+// one
+func serveFile(w http.ResponseWriter, r *http.Request, filename string) {
+	var (
+		hasVariants bool
+		served      = filename
+		encoding    string
+	)
+	if precompressExts[filepath.Ext(filename)] {
+		for _, variant := range encodingVariants {
+			if _, err := os.Stat(filename + variant.ext); err != nil {
+				continue
+			}
+			hasVariants = true
+			if encoding == "" && acceptsEncoding(r.Header.Get("Accept-Encoding"), variant.encoding) {
+				served = filename + variant.ext
+				encoding = variant.encoding
+			}
+		}
+	}
+	file, err := os.Open(served)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	if hasVariants {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+	// Cache-Control from '_headers' takes precedence over the defaults
+	if RETRO_CMD == string(KindServeCommand) {
+		cacheControl := userConfig.Serve.CacheControl.Hashed
+		if !isHashedFilename(filename) {
+			etag, err := getETag(file, info)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			cacheControl = userConfig.Serve.CacheControl.Unhashed
+			w.Header().Set("ETag", etag)
+		}
+		if w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+	}
+	// Use the uncompressed filename so the Content-Type is correct
+	http.ServeContent(w, r, filename, info.ModTime(), file)
+}
@@ -0,0 +1,83 @@
+package retro
+
+import (
+	"mime"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		encoding string
+		want     bool
+	}{
+		{header: "", encoding: "gzip", want: false},
+		{header: "gzip", encoding: "gzip", want: true},
+		{header: "gzip, deflate, br", encoding: "br", want: true},
+		{header: "GZIP", encoding: "gzip", want: true},
+		{header: "deflate", encoding: "gzip", want: false},
+		{header: "gzip;q=0.5", encoding: "gzip", want: true},
+		{header: "gzip; q=0.5, br;q=1.0", encoding: "br", want: true},
+		{header: "gzip;q=0", encoding: "gzip", want: false},
+		{header: "br;q=0, gzip", encoding: "br", want: false},
+		{header: "*", encoding: "br", want: true},
+		{header: "*;q=0", encoding: "br", want: false},
+		{header: "gzip;q=0, *", encoding: "gzip", want: false},
+		{header: "br, *;q=0", encoding: "br", want: true},
+	}
+	for _, test := range tests {
+		expect.DeepEqual(t, acceptsEncoding(test.header, test.encoding), test.want)
+	}
+}
+
+func TestServeFile(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		js  = filepath.Join(dir, "client.js")
+		css = filepath.Join(dir, "client.css")
+	)
+	must(os.WriteFile(js, []byte("js"), 0644))
+	must(os.WriteFile(js+".br", []byte("js br"), 0644))
+	must(os.WriteFile(js+".gz", []byte("js gz"), 0644))
+	must(os.WriteFile(css, []byte("css"), 0644))
+
+	tests := []struct {
+		filename        string
+		acceptEncoding  string
+		body            string
+		contentEncoding string
+		vary            string
+	}{
+		// Prefers br over gzip
+		{filename: js, acceptEncoding: "gzip, br", body: "js br", contentEncoding: "br", vary: "Accept-Encoding"},
+		{filename: js, acceptEncoding: "gzip", body: "js gz", contentEncoding: "gzip", vary: "Accept-Encoding"},
+		{filename: js, acceptEncoding: "br;q=0, gzip", body: "js gz", contentEncoding: "gzip", vary: "Accept-Encoding"},
+		{filename: js, acceptEncoding: "", body: "js", contentEncoding: "", vary: "Accept-Encoding"},
+		// Falls back to the uncompressed file without '.br' or '.gz' files
+		{filename: css, acceptEncoding: "gzip, br", body: "css", contentEncoding: "", vary: ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/"+filepath.Base(test.filename), nil)
+		if test.acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", test.acceptEncoding)
+		}
+		serveFile(w, r, test.filename)
+		expect.DeepEqual(t, w.Code, 200)
+		expect.DeepEqual(t, w.Body.String(), test.body)
+		expect.DeepEqual(t, w.Header().Get("Content-Encoding"), test.contentEncoding)
+		expect.DeepEqual(t, w.Header().Get("Vary"), test.vary)
+	}
+
+	// Uses the Content-Type of the uncompressed file
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/client.js", nil)
+	r.Header.Set("Accept-Encoding", "br")
+	serveFile(w, r, js)
+	expect.DeepEqual(t, w.Header().Get("Content-Type"), mime.TypeByExtension(".js"))
+}
@@ -25,12 +25,25 @@ const (
 
 import { App } from "./App"
 
-ReactDOM.render(
+// Routes are prerendered without the base path, e.g. '/app/about' is '/about'
+const base = process.env.RETRO_BASE.replace(/\/$/, "")
+const path = window.location.pathname.startsWith(base + "/")
+	? window.location.pathname.slice(base.length)
+	: window.location.pathname
+
+const root = document.getElementById("root")
+const app = (
 	<React.StrictMode>
-		<App />
-	</React.StrictMode>,
-	document.getElementById("root"),
-)`
+		<App path={path} />
+	</React.StrictMode>
+)
+
+// Hydrate routes prerendered by 'retro build'
+if (root.hasChildNodes()) {
+	ReactDOM.hydrate(app, root)
+} else {
+	ReactDOM.render(app, root)
+}`
 
 	// The JavaScript app entry point
 	appJSEntryPoint = `import "./App.css"
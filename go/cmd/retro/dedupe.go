@@ -0,0 +1,111 @@
+package retro
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zaydek/retro/go/cmd/retro/unix"
+)
+
+// Describes one copy of a package in the output
+type packageCopy struct {
+	dir     string // e.g. 'node_modules/a/node_modules/b'
+	version string // Empty when 'package.json' is missing
+	bytes   int64
+}
+
+// Describes a package bundled from more than one directory
+type duplicatePackage struct {
+	name   string
+	copies []packageCopy // Sorted by bytes
+	wasted int64         // Bytes of every copy but the largest
+}
+
+func (d duplicatePackage) String() string {
+	var copies []string
+	for _, copy := range d.copies {
+		version := copy.version
+		if version == "" {
+			version = "unknown version"
+		}
+		copies = append(copies, fmt.Sprintf("%s at %s", version, copy.dir))
+	}
+	return fmt.Sprintf("%s is bundled %d times (%s); %s wasted",
+		d.name,
+		len(d.copies),
+		strings.Join(copies, ", "),
+		unix.HumanReadable(d.wasted),
+	)
+}
+
+// Reads the version from '<dir>/package.json'
+func readPackageVersion(dir string) string {
+	bstr, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(bstr, &pkg); err != nil {
+		return ""
+	}
+	return pkg.Version
+}
+
+// Finds packages bundled from more than one 'node_modules' directory, for
+// example when nested dependencies resolve to different versions
+func findDuplicatePackages(msg Message) ([]duplicatePackage, error) {
+	byDir := map[string]int64{}
+	for _, info := range []BundleInfo{msg.VendorInfo, msg.ClientInfo} {
+		meta, err := info.getMetafile()
+		if err != nil {
+			return nil, err
+		}
+		for path, output := range meta.Outputs {
+			if ext := filepath.Ext(path); ext != ".js" && ext != ".css" {
+				continue
+			}
+			for input, in := range output.Inputs {
+				if dir := getPackageDir(input); dir != "" {
+					byDir[dir] += in.BytesInOutput
+				}
+			}
+		}
+	}
+
+	byName := map[string][]packageCopy{}
+	for dir, size := range byDir {
+		name := getPackageName(dir + "/")
+		byName[name] = append(byName[name], packageCopy{dir: dir, version: readPackageVersion(dir), bytes: size})
+	}
+
+	var duplicates []duplicatePackage
+	for name, copies := range byName {
+		if len(copies) < 2 {
+			continue
+		}
+		sort.Slice(copies, func(i, j int) bool {
+			if copies[i].bytes != copies[j].bytes {
+				return copies[i].bytes > copies[j].bytes
+			}
+			return copies[i].dir < copies[j].dir
+		})
+		duplicate := duplicatePackage{name: name, copies: copies}
+		for _, copy := range copies[1:] {
+			duplicate.wasted += copy.bytes
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].wasted != duplicates[j].wasted {
+			return duplicates[i].wasted > duplicates[j].wasted
+		}
+		return duplicates[i].name < duplicates[j].name
+	})
+	return duplicates, nil
+}
@@ -0,0 +1,82 @@
+package retro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func writePackageJSON(t *testing.T, dir, version string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"version": "`+version+`"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindDuplicatePackages(t *testing.T) {
+	root := filepath.ToSlash(t.TempDir())
+	writePackageJSON(t, root+"/node_modules/lodash", "4.17.21")
+	writePackageJSON(t, root+"/node_modules/a/node_modules/lodash", "3.10.1")
+
+	msg := Message{
+		VendorInfo: BundleInfo{Metafile: map[string]interface{}{
+			"outputs": map[string]interface{}{
+				"out/vendor.js": map[string]interface{}{
+					"inputs": map[string]interface{}{
+						root + "/node_modules/lodash/lodash.js":                 map[string]interface{}{"bytesInOutput": 500},
+						root + "/node_modules/a/index.js":                       map[string]interface{}{"bytesInOutput": 10},
+						root + "/node_modules/a/node_modules/lodash/index.js":   map[string]interface{}{"bytesInOutput": 200},
+						root + "/node_modules/a/node_modules/lodash/_helper.js": map[string]interface{}{"bytesInOutput": 100},
+					},
+				},
+			},
+		}},
+		ClientInfo: BundleInfo{Metafile: map[string]interface{}{
+			"outputs": map[string]interface{}{
+				"out/client.js": map[string]interface{}{
+					"inputs": map[string]interface{}{
+						"src/App.js": map[string]interface{}{"bytesInOutput": 400},
+					},
+				},
+			},
+		}},
+	}
+	duplicates, err := findDuplicatePackages(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect.DeepEqual(t, duplicates, []duplicatePackage{
+		{
+			name: "lodash",
+			copies: []packageCopy{
+				{dir: root + "/node_modules/lodash", version: "4.17.21", bytes: 500},
+				{dir: root + "/node_modules/a/node_modules/lodash", version: "3.10.1", bytes: 300},
+			},
+			wasted: 300,
+		},
+	})
+}
+
+func TestFindDuplicatePackagesNone(t *testing.T) {
+	msg := Message{
+		VendorInfo: BundleInfo{Metafile: map[string]interface{}{
+			"outputs": map[string]interface{}{
+				"out/vendor.js": map[string]interface{}{
+					"inputs": map[string]interface{}{
+						"node_modules/react/index.js":     map[string]interface{}{"bytesInOutput": 100},
+						"node_modules/react-dom/index.js": map[string]interface{}{"bytesInOutput": 100},
+					},
+				},
+			},
+		}},
+	}
+	duplicates, err := findDuplicatePackages(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect.DeepEqual(t, len(duplicates), 0)
+}
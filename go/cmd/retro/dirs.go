@@ -0,0 +1,158 @@
+package retro
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Describes a directory for errors, e.g. 'source directory'
+type namedDir struct {
+	name string
+	dir  string
+}
+
+// Checks whether two paths refer to the same directory
+func isSameDir(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// Validates the source, static, and output directories before anything is read
+// or written. Directories set by flags, the environment, or 'retro.config.js'
+// are validated the same way.
+func validateDirs(commandKind CommandKind) error {
+	var (
+		src = namedDir{name: "source directory", dir: RETRO_SRC_DIR}
+		www = namedDir{name: "static directory", dir: RETRO_WWW_DIR}
+		out = namedDir{name: "output directory", dir: RETRO_OUT_DIR}
+	)
+
+	// Serve only reads the output directory
+	if commandKind == KindServeCommand {
+		info, err := os.Stat(out.dir)
+		if os.IsNotExist(err) {
+			return newConfigError(fmt.Sprintf("No %s found at '%s'. Run 'retro build' or use '--out'.", out.name, out.dir))
+		} else if err != nil {
+			return err
+		} else if !info.IsDir() {
+			return newConfigError(fmt.Sprintf("The %s '%s' is a file.", out.name, out.dir))
+		}
+		return nil
+	}
+
+	// Missing source and static directories are scaffolded
+	for _, d := range []namedDir{src, www, out} {
+		if info, err := os.Stat(d.dir); err == nil && !info.IsDir() {
+			return newConfigError(fmt.Sprintf("The %s '%s' is a file.", d.name, d.dir))
+		}
+	}
+	for _, pair := range [][2]namedDir{{src, www}, {src, out}, {www, out}} {
+		if isSameDir(pair[0].dir, pair[1].dir) {
+			return newConfigError(fmt.Sprintf("The %s and %s must be different; both are '%s'.", pair[0].name, pair[1].name, pair[0].dir))
+		}
+	}
+	// The static directory is copied to '<out>/<www>' and served from '/<www>/'
+	if filepath.IsAbs(www.dir) || www.dir == ".." || strings.HasPrefix(www.dir, ".."+string(filepath.Separator)) {
+		return newConfigError(fmt.Sprintf("The %s must be inside the project; used '%s'.", www.name, www.dir))
+	}
+	return nil
+}
+
+// The marker file written to directories Retro generates. Directories without
+// the marker file are never deleted.
+const retroMarkerFilename = ".retro"
+
+// Writes the marker file to a generated directory
+func writeMarkerFile(dir string) error {
+	filename := filepath.Join(dir, retroMarkerFilename)
+	return os.WriteFile(filename, []byte("This directory is generated by Retro and deleted on every build.\n"), 0644)
+}
+
+// Resolves a directory to an absolute path. Symlinks are resolved when the
+// directory exists.
+func resolveDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+	return abs, nil
+}
+
+// Checks whether dir is parent or is inside of parent
+func isInsideDir(parent, dir string) bool {
+	rel, err := filepath.Rel(parent, dir)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// Guards a generated directory before it is deleted. The directory must not be
+// the project root, contain the project root, or contain the source or static
+// directories. Directories outside of the project are only deleted with
+// '--force'. Non-empty directories must have the marker file.
+func guardRemoveDir(dir string, force bool) error {
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if !info.IsDir() {
+		return newConfigError(fmt.Sprintf("Refusing to delete '%s' because it is a file.", dir))
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if root, err = resolveDir(root); err != nil {
+		return err
+	}
+	abs, err := resolveDir(dir)
+	if err != nil {
+		return err
+	}
+
+	if isInsideDir(abs, root) {
+		return newConfigError(fmt.Sprintf("Refusing to delete '%s' because it contains the project.", dir))
+	}
+	for _, d := range []namedDir{
+		{name: "source directory", dir: RETRO_SRC_DIR},
+		{name: "static directory", dir: RETRO_WWW_DIR},
+	} {
+		absDir, err := resolveDir(d.dir)
+		if err != nil {
+			return err
+		}
+		if isInsideDir(abs, absDir) {
+			return newConfigError(fmt.Sprintf("Refusing to delete '%s' because it contains the %s '%s'.", dir, d.name, d.dir))
+		}
+	}
+	if !force && !isInsideDir(root, abs) {
+		return newConfigError(fmt.Sprintf("Refusing to delete '%s' because it is outside of the project. Use '--force' to delete it anyway.", dir))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, retroMarkerFilename)); os.IsNotExist(err) {
+		return newConfigError(fmt.Sprintf("Refusing to delete '%s' because it has no '%s' file and may not have been generated by Retro. "+
+			"Delete it manually if it is safe to do so.", dir, retroMarkerFilename))
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package retro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func withDirs(t *testing.T, src, www, out string) {
+	prevSrc, prevWWW, prevOut := RETRO_SRC_DIR, RETRO_WWW_DIR, RETRO_OUT_DIR
+	t.Cleanup(func() { RETRO_SRC_DIR, RETRO_WWW_DIR, RETRO_OUT_DIR = prevSrc, prevWWW, prevOut })
+	RETRO_SRC_DIR, RETRO_WWW_DIR, RETRO_OUT_DIR = src, www, out
+}
+
+func TestIsSameDir(t *testing.T) {
+	expect.DeepEqual(t, isSameDir("out", "out/"), true)
+	expect.DeepEqual(t, isSameDir("out", "./out"), true)
+	expect.DeepEqual(t, isSameDir("out", "dist"), false)
+}
+
+func TestValidateDirs(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	withDirs(t, "src", "www", "out")
+	expect.DeepEqual(t, validateDirs(KindBuildCommand), nil)
+
+	withDirs(t, file, "www", "out")
+	expect.NotDeepEqual(t, validateDirs(KindBuildCommand), nil)
+
+	withDirs(t, "app", "app", "out")
+	expect.NotDeepEqual(t, validateDirs(KindDevCommand), nil)
+
+	withDirs(t, "src", "www", "./src")
+	expect.NotDeepEqual(t, validateDirs(KindBuildCommand), nil)
+
+	withDirs(t, "src", "../www", "out")
+	expect.NotDeepEqual(t, validateDirs(KindBuildCommand), nil)
+
+	withDirs(t, "src", "www", filepath.Join(dir, "missing"))
+	expect.NotDeepEqual(t, validateDirs(KindServeCommand), nil)
+
+	withDirs(t, "src", "www", dir)
+	expect.DeepEqual(t, validateDirs(KindServeCommand), nil)
+}
+
+func TestIsInsideDir(t *testing.T) {
+	expect.DeepEqual(t, isInsideDir("/app", "/app"), true)
+	expect.DeepEqual(t, isInsideDir("/app", "/app/out"), true)
+	expect.DeepEqual(t, isInsideDir("/app", "/"), false)
+	expect.DeepEqual(t, isInsideDir("/app", "/app-name"), false)
+	expect.DeepEqual(t, isInsideDir("/app", "/app/..out"), true)
+}
+
+func TestGuardRemoveDir(t *testing.T) {
+	root := t.TempDir()
+	prev, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(prev) })
+
+	for _, dir := range []string{"src", "www", "out", "empty", "other", "app/src"} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writeMarkerFile("out"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("other/index.html", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	withDirs(t, "src", "www", "out")
+
+	expect.DeepEqual(t, guardRemoveDir("out", false), nil)
+	expect.DeepEqual(t, guardRemoveDir("missing", false), nil)
+	expect.DeepEqual(t, guardRemoveDir("empty", false), nil)
+
+	// No marker file
+	expect.NotDeepEqual(t, guardRemoveDir("other", false), nil)
+	expect.NotDeepEqual(t, guardRemoveDir("other", true), nil)
+
+	// The project root or an ancestor
+	expect.NotDeepEqual(t, guardRemoveDir(".", true), nil)
+	expect.NotDeepEqual(t, guardRemoveDir("..", true), nil)
+
+	// Contains the source directory
+	withDirs(t, "app/src", "www", "app")
+	expect.NotDeepEqual(t, guardRemoveDir("app", true), nil)
+
+	// Outside of the project
+	outside := t.TempDir()
+	if err := writeMarkerFile(outside); err != nil {
+		t.Fatal(err)
+	}
+	withDirs(t, "src", "www", outside)
+	expect.NotDeepEqual(t, guardRemoveDir(outside, false), nil)
+	expect.DeepEqual(t, guardRemoveDir(outside, true), nil)
+}
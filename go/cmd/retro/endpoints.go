@@ -0,0 +1,131 @@
+package retro
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zaydek/retro/go/pkg/manifest"
+)
+
+// Describes the JSON response of the build endpoint
+type buildInfo struct {
+	Version string            `json:"version"`
+	BuiltAt time.Time         `json:"builtAt"`
+	Outputs map[string]string `json:"outputs"` // Output paths to SHA-256 digests
+}
+
+func hashFile(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Reads the manifest of the served build. Builds rewrite the manifest so it
+// always describes the current build.
+func readServedManifest() (manifest.Manifest, error) {
+	return manifest.Read(filepath.Join(RETRO_OUT_DIR, manifest.Filename))
+}
+
+// Checks every page's HTML parses and every output the manifest references
+// exists. HTML parses when it has a root element to render to and is not
+// truncated.
+func checkReady(m manifest.Manifest) error {
+	for _, p := range pages {
+		bstr, err := os.ReadFile(p.htmlOutput())
+		if err != nil {
+			return fmt.Errorf("%s: missing output", p.htmlOutput())
+		}
+		contents := string(bstr)
+		if !strings.Contains(contents, `<div id="root">`) {
+			return fmt.Errorf("%s: no root element", p.htmlOutput())
+		} else if !strings.Contains(contents, "</html>") {
+			return fmt.Errorf("%s: truncated", p.htmlOutput())
+		}
+	}
+	var outputs []string
+	for rel := range m.Outputs {
+		outputs = append(outputs, rel)
+	}
+	sort.Strings(outputs)
+	for _, rel := range outputs {
+		filename := filepath.Join(RETRO_OUT_DIR, filepath.FromSlash(rel))
+		if _, err := os.Stat(filename); err != nil {
+			return fmt.Errorf("%s: missing output", filename)
+		}
+	}
+	return nil
+}
+
+// Gets build info from the manifest so outputs are not rehashed per request
+func getBuildInfo(m manifest.Manifest) buildInfo {
+	info := buildInfo{
+		Version: m.RetroVersion,
+		BuiltAt: m.BuiltAt,
+		Outputs: map[string]string{},
+	}
+	for rel, output := range m.Outputs {
+		info.Outputs[rel] = output.Hash
+	}
+	return info
+}
+
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintln(w, "ok")
+}
+
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	m, err := readServedManifest()
+	if err == nil {
+		err = checkReady(m)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+func handleBuildInfo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	m, err := readServedManifest()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	bstr, err := json.MarshalIndent(getBuildInfo(m), "", "\t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(bstr, '\n'))
+}
+
+// Registers health, readiness, and build-info endpoints for probes
+func handleEndpoints() {
+	if !userConfig.Serve.Endpoints.Enabled {
+		return
+	}
+	prefix := withBase(normalizeBase(userConfig.Serve.Endpoints.Prefix))
+	http.HandleFunc(prefix+"healthz", handleHealthz)
+	http.HandleFunc(prefix+"readyz", handleReadyz)
+	http.HandleFunc(prefix+"build", handleBuildInfo)
+}
@@ -0,0 +1,119 @@
+package retro
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+	"github.com/zaydek/retro/go/pkg/manifest"
+)
+
+const testIndexHTML = `<!DOCTYPE html>
+<html>
+	<body>
+		<div id="root"></div>
+	</body>
+</html>
+`
+
+// Writes a build with a manifest to a temporary output directory
+func setupEndpointsTest(t *testing.T) manifest.Manifest {
+	prevOut, prevPages := RETRO_OUT_DIR, pages
+	t.Cleanup(func() { RETRO_OUT_DIR, pages = prevOut, prevPages })
+	RETRO_OUT_DIR, pages = t.TempDir(), []page{newIndexPage()}
+
+	must(os.WriteFile(filepath.Join(RETRO_OUT_DIR, "index.html"), []byte(testIndexHTML), 0644))
+	must(os.WriteFile(filepath.Join(RETRO_OUT_DIR, "client.js"), []byte("console.log(1)"), 0644))
+	m := manifest.Manifest{
+		Mode:         manifest.ModeBuild,
+		RetroVersion: "0.1.0",
+		BuiltAt:      time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		Entries:      map[string]string{"client.js": "client.js"},
+		Outputs: map[string]manifest.Output{
+			"client.js": {Kind: manifest.KindClientJS, Bytes: 14, Hash: "abc"},
+		},
+	}
+	must(m.Write(filepath.Join(RETRO_OUT_DIR, manifest.Filename)))
+	return m
+}
+
+func TestHandleHealthz(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleHealthz(w, httptest.NewRequest("GET", "/__retro/healthz", nil))
+	expect.DeepEqual(t, w.Code, http.StatusOK)
+	expect.DeepEqual(t, w.Body.String(), "ok\n")
+	expect.DeepEqual(t, w.Header().Get("Cache-Control"), "no-store")
+}
+
+func TestHandleReadyz(t *testing.T) {
+	setupEndpointsTest(t)
+
+	w := httptest.NewRecorder()
+	handleReadyz(w, httptest.NewRequest("GET", "/__retro/readyz", nil))
+	expect.DeepEqual(t, w.Code, http.StatusOK)
+	expect.DeepEqual(t, w.Header().Get("Cache-Control"), "no-store")
+
+	// Not ready when an output the manifest references is missing
+	must(os.Remove(filepath.Join(RETRO_OUT_DIR, "client.js")))
+	w = httptest.NewRecorder()
+	handleReadyz(w, httptest.NewRequest("GET", "/__retro/readyz", nil))
+	expect.DeepEqual(t, w.Code, http.StatusServiceUnavailable)
+
+	// Not ready without a manifest
+	must(os.Remove(filepath.Join(RETRO_OUT_DIR, manifest.Filename)))
+	w = httptest.NewRecorder()
+	handleReadyz(w, httptest.NewRequest("GET", "/__retro/readyz", nil))
+	expect.DeepEqual(t, w.Code, http.StatusServiceUnavailable)
+}
+
+func TestHandleReadyzMissingPage(t *testing.T) {
+	setupEndpointsTest(t)
+	must(os.Remove(filepath.Join(RETRO_OUT_DIR, "index.html")))
+
+	w := httptest.NewRecorder()
+	handleReadyz(w, httptest.NewRequest("GET", "/__retro/readyz", nil))
+	expect.DeepEqual(t, w.Code, http.StatusServiceUnavailable)
+}
+
+func TestHandleBuildInfo(t *testing.T) {
+	m := setupEndpointsTest(t)
+
+	w := httptest.NewRecorder()
+	handleBuildInfo(w, httptest.NewRequest("GET", "/__retro/build", nil))
+	expect.DeepEqual(t, w.Code, http.StatusOK)
+	expect.DeepEqual(t, w.Header().Get("Content-Type"), "application/json")
+
+	var info buildInfo
+	must(json.Unmarshal(w.Body.Bytes(), &info))
+	expect.DeepEqual(t, info, buildInfo{
+		Version: "0.1.0",
+		BuiltAt: m.BuiltAt,
+		Outputs: map[string]string{"client.js": "abc"},
+	})
+
+	// Not ready without a manifest
+	must(os.Remove(filepath.Join(RETRO_OUT_DIR, manifest.Filename)))
+	w = httptest.NewRecorder()
+	handleBuildInfo(w, httptest.NewRequest("GET", "/__retro/build", nil))
+	expect.DeepEqual(t, w.Code, http.StatusServiceUnavailable)
+}
+
+func TestHandleReadyzBadPage(t *testing.T) {
+	setupEndpointsTest(t)
+
+	for _, contents := range []string{
+		"",
+		testIndexHTML[:len(testIndexHTML)/2], // Truncated
+		"<!DOCTYPE html><html><body></body></html>",
+	} {
+		must(os.WriteFile(filepath.Join(RETRO_OUT_DIR, "index.html"), []byte(contents), 0644))
+		w := httptest.NewRecorder()
+		handleReadyz(w, httptest.NewRequest("GET", "/__retro/readyz", nil))
+		expect.DeepEqual(t, w.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -72,6 +72,12 @@ func guardHTMLEntryPoint() error {
 	}
 
 	// www/index.html (2 of 2)
+	return guardHTMLEntryPointFile(filename)
+}
+
+// Guards an HTML entry point for the same tags as 'www/index.html'. Every page
+// of a multi-page app uses these tags; they are rewritten per page.
+func guardHTMLEntryPointFile(filename string) error {
 	bstr, err := os.ReadFile(filename)
 	if err != nil {
 		return err
@@ -200,6 +206,7 @@ func guardAppJSEntryPoint() error {
 //
 // - www/index.html
 // - src/index.js
+// - www/<name>.html for every other page
 //
 func guardEntryPoints() error {
 	if err := guardHTMLEntryPoint(); err != nil {
@@ -208,6 +215,15 @@ func guardEntryPoints() error {
 	if err := guardJSEntryPoint(); err != nil {
 		return err
 	}
+	var err error
+	if pages, err = discoverPages(); err != nil {
+		return err
+	}
+	for _, p := range pages[1:] {
+		if err := guardHTMLEntryPointFile(p.htmlEntryPoint()); err != nil {
+			return err
+		}
+	}
 	// if err := guardAppJSEntryPoint(); err != nil {
 	// 	return err
 	// }
@@ -220,37 +236,60 @@ type entryPoints struct {
 	clientJS  string // The bundled client JS filename
 }
 
-func copyIndexHTMLEntryPoint(entries entryPoints) error {
-	// www/index.html
-	srcPath := filepath.Join(RETRO_WWW_DIR, "index.html")
+// Copies every page's HTML entry point to the output directory, e.g.
+// 'www/pricing.html' to 'out/pricing.html', and rewrites its entry points
+func copyHTMLEntryPoints(msg Message) error {
+	for _, p := range pages {
+		if err := copyHTMLEntryPoint(p, msg.getPageEntrypoints(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Copies one page's HTML entry point and rewrites '/client.css', '/vendor.js',
+// and '/client.js' to the page's outputs
+func copyHTMLEntryPoint(p page, entries entryPoints) error {
+	// www/<name>.html
+	srcPath := p.htmlEntryPoint()
 	bstr, err := os.ReadFile(srcPath)
 	if err != nil {
 		return err
 	}
+	// Subresource Integrity
+	var integrity [3]string
+	for x, entry := range []string{entries.clientCSS, entries.vendorJS, entries.clientJS} {
+		if integrity[x], err = getIntegrityAttrs(entry); err != nil {
+			return err
+		}
+	}
 	// <link rel="stylesheet" href="/client.css" />
 	contents := string(bstr)
 	contents = strings.Replace(
 		contents,
 		`<link rel="stylesheet" href="/client.css" />`,
-		fmt.Sprintf(`<link rel="stylesheet" href="/%s" />`, entries.clientCSS),
+		fmt.Sprintf(`<link rel="stylesheet" href="%s%s"%s />`, RETRO_BASE, entries.clientCSS, integrity[0]),
 		1,
 	)
 	// <script src="/vendor.js" type="module"></script>
 	contents = strings.Replace(
 		contents,
 		`<script src="/vendor.js" type="module"></script>`,
-		fmt.Sprintf(`<script src="/%s" type="module"></script>`, entries.vendorJS),
+		fmt.Sprintf(`<script src="%s%s" type="module"%s></script>`, RETRO_BASE, entries.vendorJS, integrity[1]),
 		1,
 	)
 	// <script src="/client.js" type="module"></script>
 	contents = strings.Replace(
 		contents,
 		`<script src="/client.js" type="module"></script>`,
-		fmt.Sprintf(`<script src="/%s" type="module"></script>`, entries.clientJS),
+		fmt.Sprintf(`<script src="%s%s" type="module"%s></script>`, RETRO_BASE, entries.clientJS, integrity[2]),
 		1,
 	)
-	// out/index.html
-	dstPath := filepath.Join(RETRO_OUT_DIR, "index.html")
+	// out/<name>.html
+	dstPath := p.htmlOutput()
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return err
+	}
 	if err := os.WriteFile(dstPath, []byte(contents), 0644); err != nil {
 		return err
 	}
@@ -0,0 +1,89 @@
+package retro
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Describes headers applied to paths matching pattern
+type headerRule struct {
+	pattern pathPattern
+	header  http.Header
+}
+
+// Matches RFC 7230 header field names
+var headerNameRegex = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// Parses Netlify-style '_headers' contents:
+//
+//   /path/*
+//     Header-Name: value
+//
+func parseHeadersFile(filename, contents string) ([]headerRule, error) {
+	var rules []headerRule
+	for lineIndex, line := range strings.Split(contents, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lineErr := func(str string) error {
+			return newConfigError(fmt.Sprintf("Line %d of '%s': %s", lineIndex+1, filename, str))
+		}
+		// Path lines are not indented
+		if line == strings.TrimLeft(line, " \t") {
+			if !strings.HasPrefix(trimmed, "/") {
+				return nil, lineErr(fmt.Sprintf("Paths must start with '/'; used '%s'.", trimmed))
+			}
+			pattern, err := compilePathPattern(trimmed)
+			if err != nil {
+				return nil, lineErr(fmt.Sprintf("Bad path '%s'.", trimmed))
+			}
+			rules = append(rules, headerRule{pattern: pattern, header: http.Header{}})
+			continue
+		}
+		if len(rules) == 0 {
+			return nil, lineErr("Headers must follow a path.")
+		}
+		index := strings.Index(trimmed, ":")
+		if index <= 0 {
+			return nil, lineErr(fmt.Sprintf("Headers must use the form 'Name: value'; used '%s'.", trimmed))
+		}
+		name := strings.TrimSpace(trimmed[:index])
+		if !headerNameRegex.MatchString(name) {
+			return nil, lineErr(fmt.Sprintf("Bad header name '%s'.", name))
+		}
+		rules[len(rules)-1].header.Add(name, strings.TrimSpace(trimmed[index+1:]))
+	}
+	return rules, nil
+}
+
+// Loads 'www/_headers' if present
+func loadHeadersFile() ([]headerRule, error) {
+	filename := filepath.Join(RETRO_WWW_DIR, "_headers")
+	bstr, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return parseHeadersFile(filename, string(bstr))
+}
+
+// Applies the headers of every rule matching path
+func applyHeaderRules(w http.ResponseWriter, rules []headerRule, path string) {
+	for _, rule := range rules {
+		if _, ok := rule.pattern.match(path); !ok {
+			continue
+		}
+		for name, values := range rule.header {
+			for _, value := range values {
+				w.Header().Add(name, value)
+			}
+		}
+	}
+}
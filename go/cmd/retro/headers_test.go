@@ -0,0 +1,53 @@
+package retro
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestParseHeadersFile(t *testing.T) {
+	rules, err := parseHeadersFile("_headers", `
+# Comment
+/*
+  X-Frame-Options: DENY
+  Permissions-Policy: camera=()
+
+/api/:id
+  Access-Control-Allow-Origin: *
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect.DeepEqual(t, len(rules), 2)
+
+	w := httptest.NewRecorder()
+	applyHeaderRules(w, rules, "/api/123")
+	expect.DeepEqual(t, w.Header(), http.Header{
+		"X-Frame-Options":             {"DENY"},
+		"Permissions-Policy":          {"camera=()"},
+		"Access-Control-Allow-Origin": {"*"},
+	})
+
+	w = httptest.NewRecorder()
+	applyHeaderRules(w, rules, "/api/123/456")
+	expect.DeepEqual(t, w.Header().Get("Access-Control-Allow-Origin"), "")
+}
+
+func TestParseHeadersFileErrors(t *testing.T) {
+	var err error
+
+	_, err = parseHeadersFile("_headers", "  X-Frame-Options: DENY")
+	expect.NotDeepEqual(t, err, nil)
+
+	_, err = parseHeadersFile("_headers", "path\n  X-Frame-Options: DENY")
+	expect.NotDeepEqual(t, err, nil)
+
+	_, err = parseHeadersFile("_headers", "/*\n  X-Frame-Options DENY")
+	expect.NotDeepEqual(t, err, nil)
+
+	_, err = parseHeadersFile("_headers", "/*\n  Bad Name: DENY")
+	expect.NotDeepEqual(t, err, nil)
+}
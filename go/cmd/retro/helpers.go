@@ -3,12 +3,15 @@ package retro
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/zaydek/retro/go/cmd/retro/unix"
+	"github.com/zaydek/retro/go/pkg/manifest"
 	"github.com/zaydek/retro/go/pkg/terminal"
 )
 
@@ -16,20 +19,24 @@ func quote(str string) string {
 	return "'" + str + "'"
 }
 
-////////////////////////////////////////////////////////////////////////////////
+// Prefixes a root-absolute path with the base path
+func withBase(path string) string {
+	return strings.TrimSuffix(RETRO_BASE, "/") + path
+}
 
-func getFilesystemPath(url string) string {
-	ret := url
-	if strings.HasSuffix(url, "/") {
-		ret += "index.html"
-	} else if strings.HasSuffix(url, "/index") {
-		ret += ".html"
-	} else if ext := filepath.Ext(url); ext == "" {
-		ret += ".html"
-	}
-	return ret
+// Strips the base path from requests so handlers see root-absolute paths, e.g.
+// '/app-name/about' is '/about'
+func stripBase(handler http.Handler) http.Handler {
+	return http.StripPrefix(strings.TrimSuffix(RETRO_BASE, "/"), handler)
+}
+
+// Gets server-sent events (SSE) for the dev command, mounted under the base path
+func getHTMLServerSentEvents() string {
+	return strings.Replace(htmlServerSentEvents, `"/__dev__"`, `"`+withBase("/__dev__")+`"`, 1)
 }
 
+////////////////////////////////////////////////////////////////////////////////
+
 // func getBrowserPath(url string) string {
 // 	ret := url
 // 	if strings.HasSuffix(url, "/index.html") {
@@ -64,18 +71,76 @@ func getDirname() (string, error) {
 
 ////////////////////////////////////////////////////////////////////////////////
 
-func buildBuildSuccessString(dir string, dur time.Duration) (string, error) {
-	var out string
+type summaryRow struct {
+	path  string
+	color func(...interface{}) string
+	raw   int64
+	gzip  int64
+	br    int64 // Zero unless precompressed
+}
+
+// Truncates the start of str to n characters. Widths too narrow for '...' keep
+// the end of str.
+func truncateStart(str string, n int) string {
+	if n < 0 {
+		n = 0
+	}
+	if len(str) <= n {
+		return str
+	} else if n <= len("...") {
+		return str[len(str)-n:]
+	}
+	return "..." + str[len(str)-n+len("..."):]
+}
+
+// Gets n spaces. Negative counts are zero spaces.
+func spaces(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat(" ", n)
+}
+
+// The narrowest a truncated path or name column gets on narrow terminals
+const minColumnWidth = 16
+
+// Fits a column to its longest value and the space left on the terminal. The
+// column is never narrower than minColumnWidth unless every value is.
+func getColumnWidth(longest, available int) int {
+	if available < minColumnWidth {
+		available = minColumnWidth
+	}
+	if longest > available {
+		return available
+	}
+	return longest
+}
+
+// Checks whether path is a file Retro writes about the build rather than a build
+// output, e.g. 'retro-manifest.json'
+func isMetadataFile(path string) bool {
+	switch filepath.Base(path) {
+	case retroMarkerFilename, manifest.Filename, sizeDiffFilename:
+		return true
+	}
+	return false
+}
+
+// Lists output files with raw, gzip, and brotli sizes, largest first.
+// Sourcemaps, precompressed files, and metadata files are skipped. Paths refer
+// to the output directory, not the staging directory.
+func listSummaryRows(dir string) ([]summaryRow, error) {
 	ls, err := unix.List(dir)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	var rows []summaryRow
 	for _, info := range ls {
 		var (
 			color = terminal.Dim
 			ext   = filepath.Ext(info.Path)
 		)
-		if info.IsDir() || strings.HasSuffix(ext, ".map") {
+		if info.IsDir() || strings.HasSuffix(ext, ".map") || isEncodingVariant(info.Path) || isMetadataFile(info.Path) {
 			continue
 		}
 		switch ext {
@@ -86,15 +151,112 @@ func buildBuildSuccessString(dir string, dur time.Duration) (string, error) {
 		case ".js":
 			color = terminal.Yellow
 		}
-		out += fmt.Sprintf("%v%s%v\n",
-			color(info.Path),
-			strings.Repeat(" ", 60-len("XXX.X KB")-len(info.Path)),
-			terminal.Dim(unix.HumanReadable(info.Size)),
-		)
+		row := summaryRow{path: getUnstagedPath(info.Path), color: color, raw: info.Size}
+		// Prefer precompressed sizes over estimates
+		if stat, err := os.Stat(info.Path + ".gz"); err == nil {
+			row.gzip = stat.Size()
+		} else if row.gzip, err = gzipSize(info.Path); err != nil {
+			return nil, err
+		}
+		if stat, err := os.Stat(info.Path + ".br"); err == nil {
+			row.br = stat.Size()
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].raw > rows[j].raw })
+	return rows, nil
+}
+
+// Formats summary rows and their total as a table fit to width
+func formatSummaryTable(rows []summaryRow, width int) string {
+	var (
+		total  = summaryRow{path: "total", color: terminal.Bold}
+		hasBr  bool
+		maxLen = len(total.path)
+	)
+	for _, row := range rows {
+		if row.br > 0 {
+			hasBr = true
+		}
+		total.raw += row.raw
+		total.gzip += row.gzip
+		total.br += row.br
+		if len(row.path) > maxLen {
+			maxLen = len(row.path)
+		}
+	}
+
+	// Fit columns to the longest path and the terminal width
+	const sizeWidth = len("  XXXX.X KB")
+	sizeCols := 2
+	if hasBr {
+		sizeCols = 3
+	}
+	pathWidth := getColumnWidth(maxLen, width-sizeWidth*(sizeCols+1))
+	formatRow := func(row summaryRow) string {
+		path := truncateStart(row.path, pathWidth)
+		line := row.color(path) + spaces(pathWidth-len(path))
+		line += terminal.Dimf("%*s", sizeWidth, unix.HumanReadable(row.raw))
+		line += terminal.Dimf("%*s", sizeWidth, unix.HumanReadable(row.gzip))
+		if hasBr {
+			if row.br > 0 {
+				line += terminal.Dimf("%*s", sizeWidth, unix.HumanReadable(row.br))
+			} else {
+				line += terminal.Dimf("%*s", sizeWidth, "-")
+			}
+		}
+		return line
+	}
+
+	var out string
+	header := spaces(pathWidth) + terminal.Dimf("%*s%*s", sizeWidth, "raw", sizeWidth, "gzip")
+	if hasBr {
+		header += terminal.Dimf("%*s", sizeWidth, "br")
+	}
+	out += fmt.Sprintln(header)
+	for _, row := range rows {
+		out += fmt.Sprintln(formatRow(row))
+	}
+	out += fmt.Sprintln(formatRow(total))
+	return out
+}
+
+func buildBuildSuccessString(rows []summaryRow, dur time.Duration, violations []budgetViolation, duplicates []duplicatePackage, strictDedupe bool) string {
+	var out string
+	overBudget := map[string]bool{}
+	for _, v := range violations {
+		for _, path := range v.paths {
+			overBudget[path] = true
+		}
+	}
+
+	for index, row := range rows {
+		if overBudget[row.path] {
+			rows[index].color = terminal.Red
+		}
+	}
+	out += formatSummaryTable(rows, terminal.Width())
+
+	if len(violations) > 0 {
+		out += fmt.Sprintln()
+		for _, v := range violations {
+			out += fmt.Sprintln(terminal.Red("Over budget: ") + v.String())
+		}
+	}
+	if len(duplicates) > 0 {
+		color := terminal.Yellow
+		if strictDedupe {
+			color = terminal.Red
+		}
+		out += fmt.Sprintln()
+		for _, d := range duplicates {
+			out += fmt.Sprintln(color("Duplicate package: ") + d.String())
+		}
 	}
 	out += fmt.Sprintln()
+	out += fmt.Sprintln(terminal.Dimf("Sourcemaps: %s", getSourcemapDescription()))
 	out += fmt.Sprintln(terminal.Dimf("%dms", dur.Milliseconds()))
-	return out, nil
+	return out
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -121,7 +283,7 @@ func buildServeSuccessString(port int, dur time.Duration) string {
 
 You can now view ` + terminal.Bold(base) + ` in the browser.
 
-  ` + terminal.Bold("Local:") + `            ` + fmt.Sprintf("http://localhost:%s", terminal.Bold(port)) + `
+  ` + terminal.Bold("Local:") + `            ` + fmt.Sprintf("http://localhost:%s%s", terminal.Bold(port), strings.TrimSuffix(RETRO_BASE, "/")) + `
 
 Note that the development build is not optimized.
 To create a production build, use ` + terminal.Cyan("npm run build") + ` or ` + terminal.Cyan("yarn build") + `.
@@ -132,8 +294,8 @@ To create a production build, use ` + terminal.Cyan("npm run build") + ` or ` +
 
 You can now view ` + terminal.Bold(base) + ` in the browser.
 
-  ` + terminal.Bold("Local:") + `            ` + fmt.Sprintf("http://localhost:%s", terminal.Bold(port)) + `
-  ` + terminal.Bold("On Your Network:") + `  ` + fmt.Sprintf("http://%s:%s", ip, terminal.Bold(port)) + `
+  ` + terminal.Bold("Local:") + `            ` + fmt.Sprintf("http://localhost:%s%s", terminal.Bold(port), strings.TrimSuffix(RETRO_BASE, "/")) + `
+  ` + terminal.Bold("On Your Network:") + `  ` + fmt.Sprintf("http://%s:%s%s", ip, terminal.Bold(port), strings.TrimSuffix(RETRO_BASE, "/")) + `
 
 Note that the development build is not optimized.
 To create a production build, use ` + terminal.Cyan("npm run build") + ` or ` + terminal.Cyan("yarn build") + `.
@@ -141,3 +303,14 @@ To create a production build, use ` + terminal.Cyan("npm run build") + ` or ` +
 ` + terminal.Dimf("%dms", dur.Milliseconds())
 	}
 }
+
+// Logs errors or the success message for the dev server. Warnings are logged
+// above the success message.
+func buildDevLogString(port int, dev TimedMessage) string {
+	if dev.msg.IsDirty() {
+		return dev.msg.String()
+	} else if dev.msg.HasWarnings() {
+		return dev.msg.String() + "\n\n" + buildServeSuccessString(port, dev.dur)
+	}
+	return buildServeSuccessString(port, dev.dur)
+}
@@ -0,0 +1,152 @@
+package retro
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+	"github.com/zaydek/retro/go/pkg/manifest"
+	"github.com/zaydek/retro/go/pkg/terminal"
+)
+
+func TestTruncateStart(t *testing.T) {
+	tests := []struct {
+		str  string
+		n    int
+		want string
+	}{
+		{str: "out/client.js", n: 20, want: "out/client.js"},
+		{str: "out/client.js", n: 13, want: "out/client.js"},
+		{str: "out/client.js", n: 10, want: "...ient.js"},
+		{str: "out/client.js", n: 4, want: "...s"},
+		{str: "out/client.js", n: 3, want: ".js"},
+		{str: "out/client.js", n: 0, want: ""},
+		{str: "out/client.js", n: -5, want: ""},
+	}
+	for _, test := range tests {
+		expect.DeepEqual(t, truncateStart(test.str, test.n), test.want)
+	}
+}
+
+func TestSpaces(t *testing.T) {
+	expect.DeepEqual(t, spaces(3), "   ")
+	expect.DeepEqual(t, spaces(0), "")
+	expect.DeepEqual(t, spaces(-3), "")
+}
+
+func TestGetColumnWidth(t *testing.T) {
+	expect.DeepEqual(t, getColumnWidth(10, 80), 10)
+	expect.DeepEqual(t, getColumnWidth(100, 80), 80)
+	expect.DeepEqual(t, getColumnWidth(100, 4), minColumnWidth)
+	expect.DeepEqual(t, getColumnWidth(100, -20), minColumnWidth)
+	expect.DeepEqual(t, getColumnWidth(5, -20), 5)
+}
+
+func TestFormatSummaryTable(t *testing.T) {
+	rows := []summaryRow{
+		{path: "out/vendor.0123456789abcdef.js", color: terminal.Normal, raw: 2048, gzip: 1024},
+		{path: "out/client.js", color: terminal.Normal, raw: 1024, gzip: 512},
+	}
+	const sizeWidth = len("  XXXX.X KB")
+
+	tests := []struct {
+		width     int
+		pathWidth int
+		path      string
+	}{
+		// Wide terminals fit the longest path
+		{width: 200, pathWidth: len(rows[0].path), path: rows[0].path},
+		// Narrow terminals truncate paths to the space left
+		{width: 20 + 3*sizeWidth, pathWidth: 20, path: "...23456789abcdef.js"},
+		// Terminals too narrow for the size columns clamp the path column
+		{width: 10, pathWidth: minColumnWidth, path: "...6789abcdef.js"},
+		{width: 0, pathWidth: minColumnWidth, path: "...6789abcdef.js"},
+	}
+	for _, test := range tests {
+		lines := strings.Split(strings.TrimSuffix(stripANSI(formatSummaryTable(rows, test.width)), "\n"), "\n")
+		expect.DeepEqual(t, len(lines), 4)
+		for _, line := range lines {
+			expect.DeepEqual(t, len(line), test.pathWidth+2*sizeWidth)
+		}
+		expect.DeepEqual(t, strings.HasPrefix(lines[1], test.path), true)
+		expect.DeepEqual(t, strings.HasPrefix(lines[3], "total "), true)
+	}
+}
+
+func TestWithBase(t *testing.T) {
+	prevBase := RETRO_BASE
+	t.Cleanup(func() { RETRO_BASE = prevBase })
+
+	tests := []struct {
+		base string
+		path string
+		want string
+	}{
+		{base: "", path: "/client.js", want: "/client.js"},
+		{base: "/", path: "/client.js", want: "/client.js"},
+		{base: "app", path: "/client.js", want: "/app/client.js"},
+		{base: "/app", path: "/client.js", want: "/app/client.js"},
+		{base: "app/", path: "/", want: "/app/"},
+		{base: "/a/b/", path: "/__dev__", want: "/a/b/__dev__"},
+	}
+	for _, test := range tests {
+		RETRO_BASE = normalizeBase(test.base)
+		expect.DeepEqual(t, withBase(test.path), test.want)
+	}
+}
+
+func TestStripBase(t *testing.T) {
+	prevBase := RETRO_BASE
+	t.Cleanup(func() { RETRO_BASE = prevBase })
+	RETRO_BASE = normalizeBase("/app")
+
+	// Mount like 'Serve'
+	mux := http.NewServeMux()
+	mux.Handle(RETRO_BASE, stripBase(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	})))
+
+	for _, test := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{path: "/app/", code: http.StatusOK, body: "/"},
+		{path: "/app/about", code: http.StatusOK, body: "/about"},
+		{path: "/app/www/logo.svg", code: http.StatusOK, body: "/www/logo.svg"},
+		{path: "/other", code: http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		expect.DeepEqual(t, w.Code, test.code)
+		if test.code == http.StatusOK {
+			expect.DeepEqual(t, w.Body.String(), test.body)
+		}
+	}
+}
+
+func TestListSummaryRows(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"index.html",
+		"client__ABCD1234.js",
+		"client__ABCD1234.js.map",
+		"client__ABCD1234.js.gz",
+		retroMarkerFilename,
+		manifest.Filename,
+		sizeDiffFilename,
+	} {
+		must(os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+	rows, err := listSummaryRows(dir)
+	must(err)
+	var paths []string
+	for _, row := range rows {
+		paths = append(paths, filepath.Base(row.path))
+	}
+	expect.DeepEqual(t, paths, []string{"client__ABCD1234.js", "index.html"})
+}
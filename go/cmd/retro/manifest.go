@@ -0,0 +1,133 @@
+package retro
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zaydek/retro/go/pkg/manifest"
+)
+
+func getOutputKind(path string, output metafileOutput, isVendor bool) manifest.OutputKind {
+	switch ext := filepath.Ext(path); {
+	case ext == ".map":
+		return manifest.KindSourcemap
+	case ext == ".js" && isVendor:
+		return manifest.KindVendorJS
+	case ext == ".js" && output.EntryPoint != "":
+		return manifest.KindClientJS
+	case ext == ".js":
+		return manifest.KindChunk
+	case ext == ".css":
+		return manifest.KindClientCSS
+	}
+	return manifest.KindAsset
+}
+
+// Creates a manifest from the vendor and client metafiles
+func newManifest(mode manifest.Mode, msg Message, dur time.Duration) (manifest.Manifest, error) {
+	entries := msg.getChunkedEntrypoints()
+	m := manifest.Manifest{
+		Version:        manifest.Version,
+		Mode:           mode,
+		RetroVersion:   os.Getenv("RETRO_V_VERSION"),
+		EsbuildVersion: msg.EsbuildVersion,
+		BuiltAt:        time.Now().UTC(),
+		DurationMs:     dur.Milliseconds(),
+		Entries: map[string]string{
+			"client.css": filepath.ToSlash(entries.clientCSS),
+			"vendor.js":  filepath.ToSlash(entries.vendorJS),
+			"client.js":  filepath.ToSlash(entries.clientJS),
+		},
+		Outputs: map[string]manifest.Output{},
+		Pages:   getPageNames(pages),
+	}
+	for _, p := range pages {
+		if p.name == "index" {
+			continue // 'client.css' and 'client.js'
+		}
+		pageEntries := msg.getPageEntrypoints(p)
+		m.Entries[p.entryName()+".css"] = filepath.ToSlash(pageEntries.clientCSS)
+		m.Entries[p.entryName()+".js"] = filepath.ToSlash(pageEntries.clientJS)
+	}
+	for _, bundle := range []struct {
+		info     BundleInfo
+		isVendor bool
+	}{
+		{info: msg.VendorInfo, isVendor: true},
+		{info: msg.ClientInfo, isVendor: false},
+	} {
+		meta, err := bundle.info.getMetafile()
+		if err != nil {
+			return manifest.Manifest{}, err
+		}
+		for path, output := range meta.Outputs {
+			// Hidden sourcemaps are not outputs
+			if RETRO_SOURCEMAP == "hidden" && filepath.Ext(path) == ".map" {
+				continue
+			}
+			hash, err := hashFile(path)
+			if err != nil {
+				return manifest.Manifest{}, err
+			}
+			rel, err := filepath.Rel(RETRO_OUT_DIR, path)
+			if err != nil {
+				return manifest.Manifest{}, err
+			}
+			m.Outputs[filepath.ToSlash(rel)] = manifest.Output{
+				Kind:   getOutputKind(path, output, bundle.isVendor),
+				Bytes:  output.Bytes,
+				Hash:   hash,
+				Inputs: len(output.Inputs),
+			}
+		}
+	}
+	return m, nil
+}
+
+// Writes 'out/retro-manifest.json'
+func writeManifest(mode manifest.Mode, msg Message, dur time.Duration) error {
+	m, err := newManifest(mode, msg, dur)
+	if err != nil {
+		return err
+	}
+	return m.Write(filepath.Join(RETRO_OUT_DIR, manifest.Filename))
+}
+
+// Guards 'retro serve' against missing, development, or newer-format builds
+func guardManifest() error {
+	filename := filepath.Join(RETRO_OUT_DIR, manifest.Filename)
+	m, err := manifest.Read(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("No build found at '%s'. Run 'retro build' before 'retro serve'.", filename)
+		}
+		return fmt.Errorf("Failed to read '%s'; %s. Run 'retro build' before 'retro serve'.", filename, strings.TrimSpace(err.Error()))
+	}
+	if m.Version > manifest.Version {
+		return fmt.Errorf("'%s' was written by a newer version of Retro (manifest version %d). Upgrade Retro or run 'retro build' before 'retro serve'.", filename, m.Version)
+	}
+	if m.Mode != manifest.ModeBuild {
+		return fmt.Errorf("'%s' contains a '%s' build. Run 'retro build' before 'retro serve'.", RETRO_OUT_DIR, m.Mode)
+	}
+	// 'retro serve' routes to the pages of the build and caches its hashed
+	// outputs
+	pages = getManifestPages(m)
+	hashedOutputs = getHashedOutputs(m)
+	return nil
+}
+
+// Gets the pages of a build. Manifests without pages describe single-page
+// apps.
+func getManifestPages(m manifest.Manifest) []page {
+	out := []page{newIndexPage()}
+	for _, name := range m.Pages {
+		if name != "index" {
+			out = append(out, page{name: name})
+		}
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package retro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+	"github.com/zaydek/retro/go/pkg/manifest"
+)
+
+func TestGuardManifest(t *testing.T) {
+	prevOut, prevPages, prevHashed := RETRO_OUT_DIR, pages, hashedOutputs
+	t.Cleanup(func() { RETRO_OUT_DIR, pages, hashedOutputs = prevOut, prevPages, prevHashed })
+	RETRO_OUT_DIR = t.TempDir()
+	filename := filepath.Join(RETRO_OUT_DIR, manifest.Filename)
+
+	err := guardManifest()
+	expect.DeepEqual(t, err.Error(), "No build found at '"+filename+"'. Run 'retro build' before 'retro serve'.")
+
+	must(os.WriteFile(filename, []byte("{"), 0644))
+	err = guardManifest()
+	expect.DeepEqual(t, err.Error(), "Failed to read '"+filename+"'; unexpected end of JSON input. Run 'retro build' before 'retro serve'.")
+
+	must(manifest.Manifest{Version: manifest.Version, Mode: manifest.ModeDev}.Write(filename))
+	err = guardManifest()
+	expect.DeepEqual(t, err.Error(), "'"+RETRO_OUT_DIR+"' contains a 'dev' build. Run 'retro build' before 'retro serve'.")
+
+	must(manifest.Manifest{Version: manifest.Version + 1, Mode: manifest.ModeBuild}.Write(filename))
+	err = guardManifest()
+	expect.DeepEqual(t, err.Error(), "'"+filename+"' was written by a newer version of Retro (manifest version 2). Upgrade Retro or run 'retro build' before 'retro serve'.")
+
+	must(manifest.Manifest{
+		Version: manifest.Version,
+		Mode:    manifest.ModeBuild,
+		Outputs: map[string]manifest.Output{"client__ABCD1234.js": {Kind: manifest.KindClientJS}},
+		Pages:   []string{"index", "pricing"},
+	}.Write(filename))
+	must(guardManifest())
+	expect.DeepEqual(t, getPageNames(pages), []string{"index", "pricing"})
+	expect.DeepEqual(t, hashedOutputs, map[string]bool{"client__ABCD1234.js": true})
+
+	// Manifests without a version are version 1
+	must(manifest.Manifest{Mode: manifest.ModeBuild}.Write(filename))
+	must(guardManifest())
+}
@@ -0,0 +1,41 @@
+package retro
+
+import "encoding/json"
+
+// Describes the parts of esbuild's metafile Retro uses
+//
+// https://esbuild.github.io/api/#metafile
+type metafile struct {
+	Inputs  map[string]metafileInput  `json:"inputs"`
+	Outputs map[string]metafileOutput `json:"outputs"`
+}
+
+type metafileInput struct {
+	Bytes int64 `json:"bytes"`
+}
+
+type metafileOutput struct {
+	Bytes      int64                          `json:"bytes"`
+	Inputs     map[string]metafileOutputInput `json:"inputs"`
+	EntryPoint string                         `json:"entryPoint"`
+}
+
+type metafileOutputInput struct {
+	BytesInOutput int64 `json:"bytesInOutput"`
+}
+
+// Decodes the untyped metafile returned by the backend
+func (b BundleInfo) getMetafile() (metafile, error) {
+	var meta metafile
+	if b.Metafile == nil {
+		return meta, nil
+	}
+	bstr, err := json.Marshal(b.Metafile)
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(bstr, &meta); err != nil {
+		return meta, err
+	}
+	return meta, nil
+}
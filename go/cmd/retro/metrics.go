@@ -0,0 +1,188 @@
+package retro
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Latency buckets in seconds
+var metricsBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+type requestsKey struct {
+	route string
+	code  int
+}
+
+type histogram struct {
+	counts []uint64 // Cumulative counts per bucket
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(v float64) {
+	for index, bucket := range metricsBuckets {
+		if v <= bucket {
+			h.counts[index]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// Describes request metrics in memory. Metrics are broken down by route class;
+// one of "asset", "html", or "redirect".
+type metrics struct {
+	mu        sync.Mutex
+	requests  map[requestsKey]uint64
+	bytes     map[string]uint64
+	durations map[string]*histogram
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:  map[requestsKey]uint64{},
+		bytes:     map[string]uint64{},
+		durations: map[string]*histogram{},
+	}
+}
+
+// Records the status and number of bytes written
+type metricsResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *metricsResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *metricsResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+type routeClassKey struct{}
+
+// Marks a request as a page or HTML file request. The class is set when the
+// file or page is resolved so HTML revalidations and errors are not counted
+// as assets.
+func setHTMLRouteClass(r *http.Request) {
+	if class, ok := r.Context().Value(routeClassKey{}).(*string); ok {
+		*class = "html"
+	}
+}
+
+// Classifies a request. Redirects are classified by status; '304 Not Modified'
+// is not a redirect.
+func getRouteClass(status int, class string) string {
+	if status >= 300 && status < 400 && status != http.StatusNotModified {
+		return "redirect"
+	} else if class != "" {
+		return class
+	}
+	return "asset"
+}
+
+func (m *metrics) observe(route string, code int, bytes int, dur time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestsKey{route: route, code: code}]++
+	m.bytes[route] += uint64(bytes)
+	h, ok := m.durations[route]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(metricsBuckets))}
+		m.durations[route] = h
+	}
+	h.observe(dur.Seconds())
+}
+
+func (m *metrics) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tm := time.Now()
+		mw := &metricsResponseWriter{ResponseWriter: w}
+		var class string
+		next.ServeHTTP(mw, r.WithContext(context.WithValue(r.Context(), routeClassKey{}, &class)))
+		if mw.status == 0 {
+			mw.status = http.StatusOK
+		}
+		m.observe(getRouteClass(mw.status, class), mw.status, mw.bytes, time.Since(tm))
+	})
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Writes metrics in the Prometheus text format
+func (m *metrics) writeTo(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requestsKeys := make([]requestsKey, 0, len(m.requests))
+	for key := range m.requests {
+		requestsKeys = append(requestsKeys, key)
+	}
+	sort.Slice(requestsKeys, func(i, j int) bool {
+		if requestsKeys[i].route != requestsKeys[j].route {
+			return requestsKeys[i].route < requestsKeys[j].route
+		}
+		return requestsKeys[i].code < requestsKeys[j].code
+	})
+	routes := make([]string, 0, len(m.durations))
+	for route := range m.durations {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	fmt.Fprintln(w, "# HELP retro_http_requests_total Total HTTP requests by route class and status code.")
+	fmt.Fprintln(w, "# TYPE retro_http_requests_total counter")
+	for _, key := range requestsKeys {
+		fmt.Fprintf(w, "retro_http_requests_total{route=%q,code=\"%d\"} %d\n", key.route, key.code, m.requests[key])
+	}
+
+	fmt.Fprintln(w, "# HELP retro_http_response_bytes_total Total HTTP response body bytes by route class.")
+	fmt.Fprintln(w, "# TYPE retro_http_response_bytes_total counter")
+	for _, route := range routes {
+		fmt.Fprintf(w, "retro_http_response_bytes_total{route=%q} %d\n", route, m.bytes[route])
+	}
+
+	fmt.Fprintln(w, "# HELP retro_http_request_duration_seconds HTTP request latencies by route class.")
+	fmt.Fprintln(w, "# TYPE retro_http_request_duration_seconds histogram")
+	for _, route := range routes {
+		h := m.durations[route]
+		for index, bucket := range metricsBuckets {
+			fmt.Fprintf(w, "retro_http_request_duration_seconds_bucket{route=%q,le=%q} %d\n", route, formatFloat(bucket), h.counts[index])
+		}
+		fmt.Fprintf(w, "retro_http_request_duration_seconds_bucket{route=%q,le=\"+Inf\"} %d\n", route, h.count)
+		fmt.Fprintf(w, "retro_http_request_duration_seconds_sum{route=%q} %s\n", route, formatFloat(h.sum))
+		fmt.Fprintf(w, "retro_http_request_duration_seconds_count{route=%q} %d\n", route, h.count)
+	}
+}
+
+// Registers the metrics endpoint and instruments next when metrics are enabled
+func handleMetrics(next http.Handler) http.Handler {
+	if !userConfig.Serve.Metrics.Enabled {
+		return next
+	}
+	m := newMetrics()
+	http.HandleFunc(withBase(userConfig.Serve.Metrics.Path), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		m.writeTo(w)
+	})
+	return m.instrument(next)
+}
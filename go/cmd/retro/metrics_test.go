@@ -0,0 +1,59 @@
+package retro
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestMetrics(t *testing.T) {
+	m := newMetrics()
+	handler := m.instrument(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			setHTMLRouteClass(r)
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<!DOCTYPE html>"))
+		case "/cached":
+			// Revalidated pages have no Content-Type
+			setHTMLRouteClass(r)
+			w.WriteHeader(http.StatusNotModified)
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		default:
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+			w.Write([]byte("console.log()"))
+		}
+	}))
+	for _, path := range []string{"/", "/", "/cached", "/old", "/client.js"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	var buf strings.Builder
+	m.writeTo(&buf)
+	out := buf.String()
+	for _, line := range []string{
+		`retro_http_requests_total{route="asset",code="200"} 1`,
+		`retro_http_requests_total{route="html",code="200"} 2`,
+		`retro_http_requests_total{route="html",code="304"} 1`,
+		`retro_http_requests_total{route="redirect",code="301"} 1`,
+		`retro_http_response_bytes_total{route="html"} 30`,
+		`retro_http_request_duration_seconds_count{route="html"} 3`,
+		`retro_http_request_duration_seconds_bucket{route="asset",le="+Inf"} 1`,
+	} {
+		expect.DeepEqual(t, strings.Contains(out, line+"\n"), true)
+	}
+}
+
+func TestGetRouteClass(t *testing.T) {
+	expect.DeepEqual(t, getRouteClass(http.StatusOK, ""), "asset")
+	expect.DeepEqual(t, getRouteClass(http.StatusOK, "html"), "html")
+	expect.DeepEqual(t, getRouteClass(http.StatusNotModified, "html"), "html")
+	expect.DeepEqual(t, getRouteClass(http.StatusNotModified, ""), "asset")
+	expect.DeepEqual(t, getRouteClass(http.StatusNotFound, "html"), "html")
+	expect.DeepEqual(t, getRouteClass(http.StatusFound, ""), "redirect")
+	expect.DeepEqual(t, getRouteClass(http.StatusPermanentRedirect, "html"), "redirect")
+}
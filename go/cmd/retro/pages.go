@@ -0,0 +1,164 @@
+package retro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Describes one page of a multi-page app. Every page has an HTML entry point in
+// the static directory, e.g. 'www/pricing.html', and a JavaScript entry point
+// in the source directory, e.g. 'src/pages/pricing.js'.
+type page struct {
+	name string // The HTML entry point without '.html', e.g. 'pricing' or 'docs/index'
+	src  string // The JavaScript entry point
+}
+
+// The index page is 'www/index.html' and 'src/index.js'
+func newIndexPage() page {
+	return page{name: "index", src: filepath.Join(RETRO_SRC_DIR, "index.js")}
+}
+
+// The discovered pages; the index page is always first
+var pages []page
+
+// Gets the esbuild entry name, e.g. 'client' or 'pages/pricing'. The index page
+// keeps 'client' so single-page apps are unchanged.
+func (p page) entryName() string {
+	if p.name == "index" {
+		return "client"
+	}
+	return "pages/" + p.name
+}
+
+// Gets the HTML entry point, e.g. 'www/pricing.html'
+func (p page) htmlEntryPoint() string {
+	return filepath.Join(RETRO_WWW_DIR, filepath.FromSlash(p.name)+".html")
+}
+
+// Gets the HTML output, e.g. 'out/pricing.html'
+func (p page) htmlOutput() string {
+	return filepath.Join(RETRO_OUT_DIR, filepath.FromSlash(p.name)+".html")
+}
+
+// Gets the route a page serves, e.g. '/', '/pricing', or '/docs/'
+func (p page) route() string {
+	if p.name == "index" {
+		return "/"
+	} else if path.Base(p.name) == "index" {
+		return "/" + strings.TrimSuffix(p.name, "index")
+	}
+	return "/" + p.name
+}
+
+// Checks whether a page serves a clean request path. Pages serve their route
+// and every path below it, e.g. '/docs/' serves '/docs' and '/docs/intro'.
+func (p page) matches(requestPath string) bool {
+	route := strings.TrimSuffix(p.route(), "/")
+	return requestPath == route || strings.HasPrefix(requestPath, route+"/")
+}
+
+// Finds the page for a clean request path. The page with the longest matching
+// route wins and the index page serves everything else.
+func findPage(pages []page, requestPath string) page {
+	found := newIndexPage()
+	for _, p := range pages {
+		if p.matches(requestPath) && len(p.route()) > len(found.route()) {
+			found = p
+		}
+	}
+	return found
+}
+
+// Discovers pages. 'www/<name>.html' is a page when 'retro.pages' names its
+// JavaScript entry point or when 'src/pages/<name>.js' exists; other HTML
+// files are copied as-is.
+func discoverPages() ([]page, error) {
+	found := map[string]page{}
+	err := filepath.WalkDir(RETRO_WWW_DIR, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(filename) != ".html" {
+			return nil
+		}
+		rel, err := filepath.Rel(RETRO_WWW_DIR, filename)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(rel), ".html")
+		if name == "index" {
+			return nil
+		}
+		if src, ok := userConfig.Pages[name]; ok {
+			found[name] = page{name: name, src: filepath.Clean(src)}
+			return nil
+		}
+		src := filepath.Join(RETRO_SRC_DIR, "pages", filepath.FromSlash(name)+".js")
+		if _, err := os.Stat(src); err == nil {
+			found[name] = page{name: name, src: src}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Guard 'retro.pages'
+	var names []string
+	for name := range userConfig.Pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		src := userConfig.Pages[name]
+		p := page{name: name, src: src}
+		if name == "index" {
+			return nil, newEntryPointError(fmt.Sprintf("Remove '%s' from 'retro.pages'; the index page is always '%s'.", name, newIndexPage().src))
+		} else if _, ok := found[name]; !ok {
+			return nil, newEntryPointError(fmt.Sprintf("Add '%s' for the page '%s' or remove it from 'retro.pages'.", p.htmlEntryPoint(), name))
+		}
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			return nil, newEntryPointError(fmt.Sprintf("Add '%s' for the page '%s' or change it in 'retro.pages'.", src, name))
+		}
+	}
+
+	out := []page{newIndexPage()}
+	for _, p := range found {
+		out = append(out, p)
+	}
+	sort.Slice(out[1:], func(i, j int) bool {
+		return out[1+i].name < out[1+j].name
+	})
+	return out, nil
+}
+
+// Gets page names, e.g. ["index", "pricing"]
+func getPageNames(pages []page) []string {
+	var names []string
+	for _, p := range pages {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// Sets 'RETRO_PAGES' so the backend adds one client entry point per page. The
+// index page is always built as 'client'.
+func setPagesEnv(pages []page) error {
+	entries := map[string]string{}
+	for _, p := range pages {
+		if p.name != "index" {
+			entries[p.entryName()] = p.src
+		}
+	}
+	bstr, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return os.Setenv("RETRO_PAGES", string(bstr))
+}
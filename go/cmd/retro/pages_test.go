@@ -0,0 +1,91 @@
+package retro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestPageRoute(t *testing.T) {
+	expect.DeepEqual(t, page{name: "index"}.route(), "/")
+	expect.DeepEqual(t, page{name: "pricing"}.route(), "/pricing")
+	expect.DeepEqual(t, page{name: "docs/index"}.route(), "/docs/")
+	expect.DeepEqual(t, page{name: "index"}.entryName(), "client")
+	expect.DeepEqual(t, page{name: "docs/index"}.entryName(), "pages/docs/index")
+}
+
+func TestFindPage(t *testing.T) {
+	pages := []page{{name: "index"}, {name: "pricing"}, {name: "docs/index"}}
+	expect.DeepEqual(t, findPage(pages, "/").name, "index")
+	expect.DeepEqual(t, findPage(pages, "/about").name, "index")
+	expect.DeepEqual(t, findPage(pages, "/pricing").name, "pricing")
+	expect.DeepEqual(t, findPage(pages, "/pricing/team").name, "pricing")
+	expect.DeepEqual(t, findPage(pages, "/pricingx").name, "index")
+	expect.DeepEqual(t, findPage(pages, "/docs").name, "docs/index")
+	expect.DeepEqual(t, findPage(pages, "/docs/intro").name, "docs/index")
+}
+
+func TestDiscoverPages(t *testing.T) {
+	root := t.TempDir()
+	prevSrc, prevWWW, prevConfig := RETRO_SRC_DIR, RETRO_WWW_DIR, userConfig
+	t.Cleanup(func() { RETRO_SRC_DIR, RETRO_WWW_DIR, userConfig = prevSrc, prevWWW, prevConfig })
+	RETRO_SRC_DIR, RETRO_WWW_DIR = filepath.Join(root, "src"), filepath.Join(root, "www")
+	userConfig = newUserConfig()
+
+	for _, filename := range []string{
+		"www/index.html",
+		"www/pricing.html",
+		"www/docs/index.html",
+		"www/static.html",
+		"src/index.js",
+		"src/pages/pricing.js",
+		"src/docs.js",
+	} {
+		filename = filepath.Join(root, filename)
+		must(os.MkdirAll(filepath.Dir(filename), 0755))
+		must(os.WriteFile(filename, nil, 0644))
+	}
+	userConfig.Pages = map[string]string{"docs/index": filepath.Join(root, "src/docs.js")}
+
+	pages, err := discoverPages()
+	must(err)
+	expect.DeepEqual(t, pages, []page{
+		{name: "index", src: filepath.Join(root, "src/index.js")},
+		{name: "docs/index", src: filepath.Join(root, "src/docs.js")},
+		{name: "pricing", src: filepath.Join(root, "src/pages/pricing.js")},
+	})
+
+	userConfig.Pages = map[string]string{"about": filepath.Join(root, "src/about.js")}
+	_, err = discoverPages()
+	expect.NotDeepEqual(t, err, nil)
+}
+
+func TestGetPageEntrypoints(t *testing.T) {
+	prevOut, prevCmd := RETRO_OUT_DIR, RETRO_CMD
+	t.Cleanup(func() { RETRO_OUT_DIR, RETRO_CMD = prevOut, prevCmd })
+	RETRO_OUT_DIR, RETRO_CMD = "out", string(KindBuildCommand)
+
+	msg := Message{
+		VendorInfo: BundleInfo{Metafile: map[string]interface{}{
+			"outputs": map[string]interface{}{"out/vendor__AAAAAAAA.js": nil},
+		}},
+		ClientInfo: BundleInfo{Metafile: map[string]interface{}{
+			"outputs": map[string]interface{}{
+				"out/client__BBBBBBBB.js":        nil,
+				"out/client__BBBBBBBB.css":       nil,
+				"out/pages/pricing__CCCCCCCC.js": nil,
+			},
+		}},
+	}
+	expect.DeepEqual(t, msg.getChunkedEntrypoints(), entryPoints{
+		clientCSS: "client__BBBBBBBB.css",
+		vendorJS:  "vendor__AAAAAAAA.js",
+		clientJS:  "client__BBBBBBBB.js",
+	})
+	expect.DeepEqual(t, msg.getPageEntrypoints(page{name: "pricing"}), entryPoints{
+		vendorJS: "vendor__AAAAAAAA.js",
+		clientJS: "pages/pricing__CCCCCCCC.js",
+	})
+}
@@ -0,0 +1,60 @@
+package retro
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Describes a Netlify-style path pattern; '*' is a splat and ':name' is a
+// placeholder for one path segment.
+type pathPattern struct {
+	source string
+	regex  *regexp.Regexp
+	names  []string
+}
+
+var placeholderRegex = regexp.MustCompile(`^:[A-Za-z_][A-Za-z0-9_]*$`)
+
+func compilePathPattern(source string) (pathPattern, error) {
+	var (
+		expr  = "^"
+		names []string
+	)
+	for index, segment := range strings.Split(source, "/") {
+		if index > 0 {
+			expr += "/"
+		}
+		if placeholderRegex.MatchString(segment) {
+			expr += "([^/]+)"
+			names = append(names, segment[1:])
+			continue
+		}
+		parts := strings.Split(segment, "*")
+		for partIndex, part := range parts {
+			if partIndex > 0 {
+				expr += "(.*)"
+				names = append(names, "splat")
+			}
+			expr += regexp.QuoteMeta(part)
+		}
+	}
+	expr += "$"
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return pathPattern{}, err
+	}
+	return pathPattern{source: source, regex: regex, names: names}, nil
+}
+
+// Matches path and returns placeholder values keyed by name
+func (p pathPattern) match(path string) (map[string]string, bool) {
+	matches := p.regex.FindStringSubmatch(path)
+	if matches == nil {
+		return nil, false
+	}
+	params := map[string]string{}
+	for index, name := range p.names {
+		params[name] = matches[index+1]
+	}
+	return params, true
+}
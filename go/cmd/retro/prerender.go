@@ -0,0 +1,76 @@
+package retro
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// The root element prerendered markup is injected into
+const rootElement = `<div id="root"></div>`
+
+// Gets the output for a prerendered route, e.g. 'out/about/index.html' for
+// '/about'. The index route overwrites 'out/index.html'.
+func getPrerenderOutput(route string) (string, error) {
+	if !strings.HasPrefix(route, "/") || strings.ContainsAny(route, "\\\x00?#") {
+		return "", errors.New("it must be a path, for example \"/about\".")
+	}
+	for _, segment := range strings.Split(route, "/") {
+		if segment == ".." || segment == "." {
+			return "", fmt.Errorf("it must not contain %q segments.", segment)
+		}
+	}
+	clean := path.Clean(route)
+	return filepath.Join(RETRO_OUT_DIR, filepath.FromSlash(clean), "index.html"), nil
+}
+
+// Injects prerendered markup into the root element so the client hydrates it
+func injectPrerenderedHTML(contents, markup string) string {
+	return strings.Replace(contents, rootElement, `<div id="root">`+markup+`</div>`, 1)
+}
+
+// Describes a route that failed to write with esbuild's message type so it is
+// formatted like build errors
+func newPrerenderError(str string) api.Message {
+	return api.Message{Text: str}
+}
+
+// Writes every prerendered route to the output directory using the index page
+// as a template. Routes served by other pages are errors; failures are returned
+// per route so every route is reported.
+func writePrerenderedRoutes(routes []RouteInfo) ([]api.Message, error) {
+	if len(routes) == 0 {
+		return nil, nil
+	}
+	index := newIndexPage()
+	bstr, err := os.ReadFile(index.htmlOutput())
+	if err != nil {
+		return nil, err
+	}
+	template := string(bstr)
+
+	var errs []api.Message
+	for _, route := range routes {
+		filename, err := getPrerenderOutput(route.Route)
+		if err != nil {
+			errs = append(errs, newPrerenderError(fmt.Sprintf("Failed to prerender route %q; %s", route.Route, err)))
+			continue
+		}
+		if p := findPage(pages, path.Clean(route.Route)); p.name != index.name {
+			errs = append(errs, newPrerenderError(fmt.Sprintf("Failed to prerender route %q; it is served by the page '%s'.", route.Route, p.htmlEntryPoint())))
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filename, []byte(injectPrerenderedHTML(template, route.HTML)), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return errs, nil
+}
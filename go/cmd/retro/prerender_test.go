@@ -0,0 +1,68 @@
+package retro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestGetPrerenderOutput(t *testing.T) {
+	prevOut := RETRO_OUT_DIR
+	t.Cleanup(func() { RETRO_OUT_DIR = prevOut })
+	RETRO_OUT_DIR = "out"
+
+	var (
+		filename string
+		err      error
+	)
+
+	filename, err = getPrerenderOutput("/")
+	must(err)
+	expect.DeepEqual(t, filename, filepath.Join("out", "index.html"))
+
+	filename, err = getPrerenderOutput("/about/")
+	must(err)
+	expect.DeepEqual(t, filename, filepath.Join("out", "about", "index.html"))
+
+	_, err = getPrerenderOutput("about")
+	expect.NotDeepEqual(t, err, nil)
+
+	_, err = getPrerenderOutput("/../about")
+	expect.NotDeepEqual(t, err, nil)
+}
+
+func TestInjectPrerenderedHTML(t *testing.T) {
+	expect.DeepEqual(t,
+		injectPrerenderedHTML(`<body><div id="root"></div></body>`, `<h1>Hello</h1>`),
+		`<body><div id="root"><h1>Hello</h1></div></body>`,
+	)
+}
+
+func TestWritePrerenderedRoutes(t *testing.T) {
+	root := t.TempDir()
+	prevOut, prevPages := RETRO_OUT_DIR, pages
+	t.Cleanup(func() { RETRO_OUT_DIR, pages = prevOut, prevPages })
+	RETRO_OUT_DIR = root
+	pages = []page{{name: "index"}, {name: "pricing"}}
+
+	must(os.WriteFile(filepath.Join(root, "index.html"), []byte(`<div id="root"></div>`), 0644))
+
+	errs, err := writePrerenderedRoutes([]RouteInfo{
+		{Route: "/", HTML: "Home"},
+		{Route: "/about", HTML: "About"},
+		{Route: "/pricing", HTML: "Pricing"},
+		{Route: "about", HTML: "About"},
+	})
+	must(err)
+	expect.DeepEqual(t, len(errs), 2)
+
+	bstr, err := os.ReadFile(filepath.Join(root, "index.html"))
+	must(err)
+	expect.DeepEqual(t, string(bstr), `<div id="root">Home</div>`)
+
+	bstr, err = os.ReadFile(filepath.Join(root, "about", "index.html"))
+	must(err)
+	expect.DeepEqual(t, string(bstr), `<div id="root">About</div>`)
+}
@@ -0,0 +1,171 @@
+package retro
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Describes a redirect or, when status is 200 or 404, a rewrite
+type redirectRule struct {
+	pattern pathPattern
+	to      string
+	status  int
+}
+
+func (r redirectRule) isRewrite() bool {
+	return r.status == http.StatusOK || r.status == http.StatusNotFound
+}
+
+// Interpolates placeholders and splats into the destination
+func (r redirectRule) destination(params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	// Replace longer names first so ':id' doesn't clobber ':idx'
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	to := r.to
+	for _, name := range names {
+		to = strings.ReplaceAll(to, ":"+name, params[name])
+	}
+	return to
+}
+
+var redirectStatuses = map[int]bool{
+	http.StatusOK:                true,
+	http.StatusMovedPermanently:  true,
+	http.StatusFound:             true,
+	http.StatusSeeOther:          true,
+	http.StatusTemporaryRedirect: true,
+	http.StatusPermanentRedirect: true,
+	http.StatusNotFound:          true,
+}
+
+// Parses Netlify-style '_redirects' contents:
+//
+//   /from /to [status]
+//
+// Bad lines are skipped and returned as errors so the remaining rules can
+// still be used.
+func parseRedirectsFile(filename, contents string) ([]redirectRule, []error) {
+	var (
+		rules []redirectRule
+		errs  []error
+	)
+	for lineIndex, line := range strings.Split(contents, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lineErr := func(str string) error {
+			return newConfigError(fmt.Sprintf("Line %d of '%s': %s", lineIndex+1, filename, str))
+		}
+		fields := strings.Fields(trimmed)
+		if len(fields) < 2 || len(fields) > 3 {
+			errs = append(errs, lineErr(fmt.Sprintf("Rules must use the form '/from /to [status]'; used '%s'.", trimmed)))
+			continue
+		}
+		from, to := fields[0], fields[1]
+		if !strings.HasPrefix(from, "/") {
+			errs = append(errs, lineErr(fmt.Sprintf("Sources must start with '/'; used '%s'.", from)))
+			continue
+		}
+		isExternal := strings.HasPrefix(to, "http://") || strings.HasPrefix(to, "https://")
+		if !strings.HasPrefix(to, "/") && !isExternal {
+			errs = append(errs, lineErr(fmt.Sprintf("Destinations must start with '/' or 'http(s)://'; used '%s'.", to)))
+			continue
+		}
+		status := http.StatusMovedPermanently
+		if len(fields) == 3 {
+			// Rules are always evaluated before files so '!' is implied
+			code, err := strconv.Atoi(strings.TrimSuffix(fields[2], "!"))
+			if err != nil || !redirectStatuses[code] {
+				errs = append(errs, lineErr(fmt.Sprintf("Unsupported status '%s'.", fields[2])))
+				continue
+			}
+			status = code
+		}
+		pattern, err := compilePathPattern(from)
+		if err != nil {
+			errs = append(errs, lineErr(fmt.Sprintf("Bad source '%s'.", from)))
+			continue
+		}
+		rule := redirectRule{pattern: pattern, to: to, status: status}
+		if rule.isRewrite() && isExternal {
+			errs = append(errs, lineErr(fmt.Sprintf("Rewrites must use a local destination; used '%s'.", to)))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, errs
+}
+
+// Loads 'www/_redirects' if present
+func loadRedirectsFile() ([]redirectRule, []error, error) {
+	filename := filepath.Join(RETRO_WWW_DIR, "_redirects")
+	bstr, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	rules, errs := parseRedirectsFile(filename, string(bstr))
+	return rules, errs, nil
+}
+
+// Applies the first rule matching the clean path p. Redirects are written to w
+// and return done; rewrites return the path and status to serve instead.
+func applyRedirectRules(w http.ResponseWriter, r *http.Request, p string, rules []redirectRule) (path string, status int, done bool) {
+	for _, rule := range rules {
+		params, ok := rule.pattern.match(p)
+		if !ok {
+			continue
+		}
+		to := rule.destination(params)
+		if rule.isRewrite() {
+			return to, rule.status, false
+		}
+		if strings.HasPrefix(to, "/") {
+			to = withBase(to)
+		}
+		if r.URL.RawQuery != "" && !strings.Contains(to, "?") {
+			to += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, to, rule.status)
+		return "", rule.status, true
+	}
+	return p, http.StatusOK, false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Overrides the status of successful responses, e.g. for custom 404 pages
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if code == http.StatusOK {
+		code = w.status
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,68 @@
+package retro
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestParseRedirectsFile(t *testing.T) {
+	rules, errs := parseRedirectsFile("_redirects", `
+# Comment
+/old                  /new
+/blog/:year/:slug     /posts/:year/:slug  302
+/docs/*               /docs/index.html    200
+/missing              /404.html           404!
+bad
+/from                 to
+/from                 /to                 418
+/proxy/*              https://example.com/:splat 200
+`)
+	expect.DeepEqual(t, len(rules), 4)
+	expect.DeepEqual(t, len(errs), 4)
+
+	var (
+		w      *httptest.ResponseRecorder
+		r      *http.Request
+		path   string
+		status int
+		done   bool
+	)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/old?a=b", nil)
+	_, _, done = applyRedirectRules(w, r, "/old", rules)
+	expect.DeepEqual(t, done, true)
+	expect.DeepEqual(t, w.Code, http.StatusMovedPermanently)
+	expect.DeepEqual(t, w.Header().Get("Location"), "/new?a=b")
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/blog/2021/hello", nil)
+	_, _, done = applyRedirectRules(w, r, "/blog/2021/hello", rules)
+	expect.DeepEqual(t, done, true)
+	expect.DeepEqual(t, w.Code, http.StatusFound)
+	expect.DeepEqual(t, w.Header().Get("Location"), "/posts/2021/hello")
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/docs/a/b", nil)
+	path, status, done = applyRedirectRules(w, r, "/docs/a/b", rules)
+	expect.DeepEqual(t, done, false)
+	expect.DeepEqual(t, path, "/docs/index.html")
+	expect.DeepEqual(t, status, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/missing", nil)
+	path, status, done = applyRedirectRules(w, r, "/missing", rules)
+	expect.DeepEqual(t, done, false)
+	expect.DeepEqual(t, path, "/404.html")
+	expect.DeepEqual(t, status, http.StatusNotFound)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/other", nil)
+	path, status, done = applyRedirectRules(w, r, "/other", rules)
+	expect.DeepEqual(t, done, false)
+	expect.DeepEqual(t, path, "/other")
+	expect.DeepEqual(t, status, http.StatusOK)
+}
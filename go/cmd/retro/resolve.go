@@ -0,0 +1,124 @@
+package retro
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	errBadPath     = errors.New("retro: bad path")
+	errOutsideRoot = errors.New("retro: path outside root")
+)
+
+// Resolves request paths to files. Paths are never resolved outside of root,
+// including through symlinks.
+type resolver struct {
+	root          string // The directory files are served from
+	trailingSlash string // One of "add", "remove", or "" to ignore
+	htmlExtension string // One of "remove" or "" to ignore
+}
+
+func newResolver(root string) resolver {
+	return resolver{
+		root:          root,
+		trailingSlash: userConfig.Serve.TrailingSlash,
+		htmlExtension: userConfig.Serve.HTMLExtension,
+	}
+}
+
+// Cleans a request path. Paths that contain '..' segments, encoded separators,
+// backslashes, or NUL bytes are rejected. Trailing slashes are preserved.
+func cleanRequestPath(u *url.URL) (string, error) {
+	escaped := strings.ToLower(u.EscapedPath())
+	if strings.Contains(escaped, "%2f") || strings.Contains(escaped, "%5c") {
+		return "", errBadPath
+	}
+	p := u.Path
+	if !strings.HasPrefix(p, "/") || strings.ContainsAny(p, "\\\x00") {
+		return "", errBadPath
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return "", errBadPath
+		}
+	}
+	clean := path.Clean(p)
+	if strings.HasSuffix(p, "/") && clean != "/" {
+		clean += "/"
+	}
+	return clean, nil
+}
+
+// Gets the canonical form of a clean path; callers should redirect when this
+// differs from the requested path
+func (r resolver) canonicalPath(p string) string {
+	if r.htmlExtension == "remove" {
+		if strings.HasSuffix(p, "/index.html") {
+			p = strings.TrimSuffix(p, "index.html")
+		} else if strings.HasSuffix(p, "/index") {
+			p = strings.TrimSuffix(p, "index")
+		} else if strings.HasSuffix(p, ".html") {
+			p = strings.TrimSuffix(p, ".html")
+		}
+	}
+	if p == "/" {
+		return p
+	}
+	switch r.trailingSlash {
+	case "add":
+		if !strings.HasSuffix(p, "/") && path.Ext(p) == "" {
+			p += "/"
+		}
+	case "remove":
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+// Gets the filename for a clean path. Extensionless paths try '<path>.html' and
+// then '<path>/index.html'. Returns os.ErrNotExist for missing files and
+// errOutsideRoot for files that escape root.
+func (r resolver) filename(p string) (string, error) {
+	var candidates []string
+	if strings.HasSuffix(p, "/") {
+		candidates = []string{p + "index.html"}
+	} else if path.Ext(p) == "" {
+		candidates = []string{p + ".html", p + "/index.html"}
+	} else {
+		candidates = []string{p}
+	}
+	for _, candidate := range candidates {
+		filename, err := r.contain(candidate)
+		if err == nil || errors.Is(err, errOutsideRoot) {
+			return filename, err
+		}
+	}
+	return "", os.ErrNotExist
+}
+
+// Joins p to root and guarantees the resulting regular file, after resolving
+// symlinks, is inside of root
+func (r resolver) contain(p string) (string, error) {
+	filename := filepath.Join(r.root, filepath.FromSlash(path.Clean("/"+p)))
+	info, err := os.Stat(filename)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", os.ErrNotExist
+	}
+	root, err := filepath.EvalSymlinks(r.root)
+	if err != nil {
+		return "", err
+	}
+	real, err := filepath.EvalSymlinks(filename)
+	if err != nil {
+		return "", os.ErrNotExist
+	}
+	rel, err := filepath.Rel(root, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", errOutsideRoot
+	}
+	return filename, nil
+}
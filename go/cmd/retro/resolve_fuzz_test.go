@@ -0,0 +1,79 @@
+//go:build go1.18
+// +build go1.18
+
+package retro
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func FuzzCleanRequestPath(f *testing.F) {
+	for _, seed := range []string{"/", "/a/b/", "/a/../b", "/a%2fb", "/%2e%2e/", "//a"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, str string) {
+		u, err := url.Parse(str)
+		if err != nil {
+			return
+		}
+		got, err := cleanRequestPath(u)
+		if err != nil {
+			return
+		}
+		if !strings.HasPrefix(got, "/") {
+			t.Fatalf("cleanRequestPath(%q) = %q; want a leading '/'", str, got)
+		}
+		for _, segment := range strings.Split(got, "/") {
+			if segment == ".." {
+				t.Fatalf("cleanRequestPath(%q) = %q; want no '..' segments", str, got)
+			}
+		}
+	})
+}
+
+func FuzzResolverFilename(f *testing.F) {
+	var (
+		dir  = f.TempDir()
+		root = filepath.Join(dir, "out")
+	)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		f.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "index.html"), nil, 0644); err != nil {
+		f.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), nil, 0644); err != nil {
+		f.Fatal(err)
+	}
+	for _, seed := range []string{"/", "/index.html", "/../secret.txt", "/a/../../secret.txt", "/%2e%2e/secret.txt"} {
+		f.Add(seed)
+	}
+	r := resolver{root: root}
+	f.Fuzz(func(t *testing.T, str string) {
+		u, err := url.Parse(str)
+		if err != nil {
+			return
+		}
+		path, err := cleanRequestPath(u)
+		if err != nil {
+			return
+		}
+		for _, p := range []string{path, r.canonicalPath(path)} {
+			filename, err := r.filename(p)
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, errOutsideRoot) {
+					t.Fatalf("r.filename(%q): unexpected error %s", p, err)
+				}
+				continue
+			}
+			if rel, err := filepath.Rel(root, filename); err != nil || strings.HasPrefix(rel, "..") {
+				t.Fatalf("r.filename(%q) = %q; want a file inside of %q", p, filename, root)
+			}
+		}
+	})
+}
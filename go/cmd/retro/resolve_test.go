@@ -0,0 +1,109 @@
+package retro
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func mustParseURL(t testing.TB, str string) *url.URL {
+	u, err := url.Parse(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestCleanRequestPath(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+		err  error
+	}{
+		{url: "/", want: "/"},
+		{url: "/a/b", want: "/a/b"},
+		{url: "/a/b/", want: "/a/b/"},
+		{url: "/a//b", want: "/a/b"},
+		{url: "/a/./b", want: "/a/b"},
+		{url: "/a/../b", err: errBadPath},
+		{url: "/a/%2e%2e/b", err: errBadPath},
+		{url: "/a%2fb", err: errBadPath},
+		{url: "/a%2Fb", err: errBadPath},
+		{url: "/a%5cb", err: errBadPath},
+		{url: "/a%00b", err: errBadPath},
+	}
+	for _, test := range tests {
+		got, err := cleanRequestPath(mustParseURL(t, test.url))
+		expect.DeepEqual(t, err, test.err)
+		expect.DeepEqual(t, got, test.want)
+	}
+}
+
+func TestCanonicalPath(t *testing.T) {
+	var r resolver
+
+	r = resolver{}
+	expect.DeepEqual(t, r.canonicalPath("/about.html"), "/about.html")
+	expect.DeepEqual(t, r.canonicalPath("/about/"), "/about/")
+
+	r = resolver{htmlExtension: "remove"}
+	expect.DeepEqual(t, r.canonicalPath("/about.html"), "/about")
+	expect.DeepEqual(t, r.canonicalPath("/index.html"), "/")
+	expect.DeepEqual(t, r.canonicalPath("/docs/index.html"), "/docs/")
+	expect.DeepEqual(t, r.canonicalPath("/docs/index"), "/docs/")
+
+	r = resolver{trailingSlash: "add"}
+	expect.DeepEqual(t, r.canonicalPath("/about"), "/about/")
+	expect.DeepEqual(t, r.canonicalPath("/client.js"), "/client.js")
+
+	r = resolver{trailingSlash: "remove", htmlExtension: "remove"}
+	expect.DeepEqual(t, r.canonicalPath("/about/"), "/about")
+	expect.DeepEqual(t, r.canonicalPath("/docs/index.html"), "/docs")
+	expect.DeepEqual(t, r.canonicalPath("/"), "/")
+}
+
+func TestResolverFilename(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		root    = filepath.Join(dir, "out")
+		outside = filepath.Join(dir, "secret.txt")
+	)
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	must(os.WriteFile(filepath.Join(root, "index.html"), nil, 0644))
+	must(os.WriteFile(filepath.Join(root, "about.html"), nil, 0644))
+	must(os.WriteFile(filepath.Join(root, "docs", "index.html"), nil, 0644))
+	must(os.WriteFile(filepath.Join(root, "client.js"), nil, 0644))
+	must(os.WriteFile(outside, nil, 0644))
+	must(os.Symlink(outside, filepath.Join(root, "secret.txt")))
+	must(os.Symlink(dir, filepath.Join(root, "parent")))
+
+	r := resolver{root: root}
+	tests := []struct {
+		path string
+		want string
+		err  error
+	}{
+		{path: "/", want: filepath.Join(root, "index.html")},
+		{path: "/about", want: filepath.Join(root, "about.html")},
+		{path: "/docs", want: filepath.Join(root, "docs", "index.html")},
+		{path: "/docs/", want: filepath.Join(root, "docs", "index.html")},
+		{path: "/client.js", want: filepath.Join(root, "client.js")},
+		{path: "/missing.js", err: os.ErrNotExist},
+		{path: "/docs/missing/", err: os.ErrNotExist},
+		{path: "/secret.txt", err: errOutsideRoot},
+		{path: "/parent/secret.txt", err: errOutsideRoot},
+	}
+	for _, test := range tests {
+		got, err := r.filename(test.path)
+		expect.DeepEqual(t, err, test.err)
+		expect.DeepEqual(t, got, test.want)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/zaydek/retro/go/cmd/format"
 	"github.com/zaydek/retro/go/cmd/retro/cli"
 	"github.com/zaydek/retro/go/pkg/ipc"
+	"github.com/zaydek/retro/go/pkg/manifest"
 	"github.com/zaydek/retro/go/pkg/terminal"
 	"github.com/zaydek/retro/go/pkg/watch"
 )
@@ -31,9 +32,12 @@ type TimedMessage struct {
 
 func (a *App) Dev(options DevOptions) error {
 	if options.WarmUpFlag {
-		var entryPointErr EntryPointError
-		if err := warmUp(a.getCommandKind()); err != nil {
-			if errors.As(err, &entryPointErr) {
+		var (
+			entryPointErr EntryPointError
+			configErr     ConfigError
+		)
+		if err := warmUp(a); err != nil {
+			if errors.As(err, &entryPointErr) || errors.As(err, &configErr) {
 				fmt.Fprintln(os.Stderr, format.Stderr(err))
 				os.Exit(1)
 			} else {
@@ -66,8 +70,8 @@ func (a *App) Dev(options DevOptions) error {
 				var msg Message
 				must(json.Unmarshal([]byte(line), &msg))
 				once.Do(func() {
-					entries := msg.getChunkedEntrypoints()
-					must(copyIndexHTMLEntryPoint(entries))
+					must(copyHTMLEntryPoints(msg))
+					must(writeManifest(manifest.ModeDev, msg, time.Since(tm)))
 					ready <- struct{}{}
 				})
 				dev <- TimedMessage{
@@ -105,60 +109,231 @@ type BuildOptions struct {
 }
 
 func (a *App) Build(options BuildOptions) error {
+	command := a.Command.(cli.BuildCommand)
+
+	// Every error crashes so builds exit 1 and '--json' logs one JSON document
+	crash := func(err error, dur time.Duration) {
+		os.Exit(logBuildFailure(os.Stdout, err, dur, command.JSON))
+	}
+
 	if options.WarmUpFlag {
-		var entryPointErr EntryPointError
-		if err := warmUp(a.getCommandKind()); err != nil {
-			if errors.As(err, &entryPointErr) {
-				fmt.Fprintln(os.Stderr, format.Stderr(err))
-				os.Exit(1)
-			} else {
+		if err := warmUp(a); err != nil {
+			crash(err, 0)
+		}
+	}
+
+	if command.Watch {
+		if err := a.buildWatch(); err != nil {
+			crash(err, 0)
+		}
+		return nil
+	}
+
+	var (
+		msg        Message
+		dur        time.Duration
+		m          manifest.Manifest
+		violations []budgetViolation
+		duplicates []duplicatePackage
+		diffStr    string
+		rows       []summaryRow
+	)
+	// Discard the staged build on every error and on failed checks to keep the
+	// last good build. Summary rows are listed before the staged build is
+	// swapped or discarded.
+	err := runStaged(func() error {
+		var err error
+		if msg, dur, err = buildOnce(true); err != nil {
+			return err
+		}
+		if RETRO_SOURCEMAP == "hidden" {
+			if err := hideSourcemaps(msg); err != nil {
+				return err
+			}
+		}
+		if command.Precompress {
+			if err := precompressDirectory(RETRO_OUT_DIR); err != nil {
 				return err
 			}
 		}
+		if m, err = newManifest(manifest.ModeBuild, msg, dur); err != nil {
+			return err
+		}
+		if err := m.Write(filepath.Join(RETRO_OUT_DIR, manifest.Filename)); err != nil {
+			return err
+		}
+		if violations, err = checkBudgets(msg); err != nil {
+			return err
+		}
+		if duplicates, err = findDuplicatePackages(msg); err != nil {
+			return err
+		}
+		if diffStr, err = writeSizeDiff(m); err != nil {
+			return err
+		}
+		if rows, err = listSummaryRows(RETRO_OUT_DIR); err != nil {
+			return err
+		}
+		if hasFailedChecks(violations, duplicates, command.StrictDedupe) {
+			return errChecksFailed
+		}
+		return nil
+	})
+	if err != nil && err != errChecksFailed {
+		crash(err, dur)
+	} else if err == nil {
+		if err := writePreviousManifestCopy(m); err != nil {
+			crash(err, dur)
+		}
+	}
+
+	if command.JSON {
+		result := newBuildJSON(msg, dur, rows, violations, duplicates, command.StrictDedupe)
+		if err := result.write(os.Stdout); err != nil {
+			crash(err, dur)
+		}
+		os.Exit(result.ExitCode)
 	}
 
+	// Log warnings above the summary
+	if msg.HasWarnings() {
+		fmt.Println(msg.String())
+		fmt.Println()
+	}
+
+	fmt.Print(buildBuildSuccessString(rows, dur, violations, duplicates, command.StrictDedupe))
+	if diffStr != "" {
+		fmt.Println()
+		fmt.Print(diffStr)
+	}
+
+	// Crash after logging to stdout
+	if err == errChecksFailed {
+		fmt.Println()
+		fmt.Println(terminal.Red(fmt.Sprintf("The build was discarded; '%s' keeps the last good build.", RETRO_OUT_DIR)))
+		os.Exit(exitCodeChecksFailed)
+	}
+
+	return nil
+}
+
+// Builds once in a persistent backend and copies every page's HTML. Prerenders
+// routes when prerender is true. Dirty builds, failed routes, and backend errors
+// return buildError.
+func buildOnce(prerender bool) (Message, time.Duration, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	stdin, stdout, stderr, err := ipc.NewPersistentCommand(ctx, "node", filepath.Join(__dirname, "scripts/backend.esbuild.js"))
 	if err != nil {
 		cancel()
-		return err
+		return Message{}, 0, err
 	}
 	defer cancel()
 
 	tm := time.Now()
 	stdin <- "build"
 
+	var msg Message
 loop:
 	for {
 		select {
 		case line := <-stdout:
-			var msg Message
 			if err := json.Unmarshal([]byte(line), &msg); err != nil {
-				return err
+				return Message{}, 0, err
 			}
-			// Log to stdout and crash
 			if msg.IsDirty() {
-				fmt.Print(msg.String())
-				cancel()
-				os.Exit(1)
+				return msg, time.Since(tm), buildError{msg: msg}
 			}
-			entries := msg.getChunkedEntrypoints()
-			if err := copyIndexHTMLEntryPoint(entries); err != nil {
-				return err
+			if err := copyHTMLEntryPoints(msg); err != nil {
+				return Message{}, 0, err
 			}
 			break loop
 		case text := <-stderr:
-			fmt.Fprintln(os.Stderr, format.StderrIPC(text))
-			cancel()
+			return Message{}, time.Since(tm), buildError{stderr: text}
+		}
+	}
+
+	if prerender {
+		stdin <- "prerender"
+		select {
+		case line := <-stdout:
+			var next Message
+			if err := json.Unmarshal([]byte(line), &next); err != nil {
+				return Message{}, 0, err
+			}
+			msg.PrerenderInfo = next.PrerenderInfo
+			msg.Routes = next.Routes
+		case text := <-stderr:
+			return Message{}, time.Since(tm), buildError{stderr: text}
+		}
+		if !msg.PrerenderInfo.HasErrors() {
+			errs, err := writePrerenderedRoutes(msg.Routes)
+			if err != nil {
+				return Message{}, 0, err
+			}
+			msg.PrerenderInfo.Errors = append(msg.PrerenderInfo.Errors, errs...)
+		}
+		if msg.IsDirty() {
+			return msg, time.Since(tm), buildError{msg: msg}
+		}
+	}
+
+	return msg, time.Since(tm), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type AnalyzeOptions struct {
+	WarmUpFlag bool
+}
+
+func (a *App) Analyze(options AnalyzeOptions) error {
+	if options.WarmUpFlag {
+		var (
+			entryPointErr EntryPointError
+			configErr     ConfigError
+		)
+		if err := warmUp(a); err != nil {
+			if errors.As(err, &entryPointErr) || errors.As(err, &configErr) {
+				fmt.Fprintln(os.Stderr, format.Stderr(err))
+				os.Exit(1)
+			} else {
+				return err
+			}
+		}
+	}
+
+	// Discard the staged build so the output directory keeps the last build and
+	// its manifest
+	var msg Message
+	err := runStagedAndDiscard(func() error {
+		var err error
+		msg, _, err = buildOnce(false)
+		return err
+	})
+	if err != nil {
+		if buildErr, ok := err.(buildError); ok {
+			buildErr.log()
 			os.Exit(1)
 		}
+		return err
 	}
 
-	str, err := buildBuildSuccessString(RETRO_OUT_DIR, time.Since(tm))
+	report, err := newAnalyzeReport(msg)
 	if err != nil {
 		return err
 	}
-	fmt.Print(str)
+	fmt.Print(report.String())
+
+	// The report is written after the staged build is discarded so the default
+	// path is in the output directory of the last build
+	filename := a.Command.(cli.AnalyzeCommand).Report
+	if filename == "" {
+		filename = filepath.Join(RETRO_OUT_DIR, analyzeReportFilename)
+	}
+	if err := report.writeHTML(filename); err != nil {
+		return err
+	}
+	fmt.Println(terminal.Dimf("Wrote %s", filename))
 
 	return nil
 }
@@ -172,19 +347,65 @@ type ServeOptions struct {
 
 func (a *App) Serve(options ServeOptions) error {
 	if options.WarmUpFlag {
+		if err := a.setEnvFromFlags(); err != nil {
+			return err
+		}
+		var configErr ConfigError
+		if err := loadUserConfig(); err != nil {
+			if errors.As(err, &configErr) {
+				fmt.Fprintln(os.Stderr, format.Stderr(err))
+				os.Exit(1)
+			} else {
+				return err
+			}
+		}
 		if err := setEnvAndGlobalVariables(KindServeCommand); err != nil {
 			return err
 		}
+		if err := validateDirs(KindServeCommand); err != nil {
+			if errors.As(err, &configErr) {
+				fmt.Fprintln(os.Stderr, format.Stderr(err))
+				os.Exit(1)
+			}
+			return err
+		}
+		if err := guardManifest(); err != nil {
+			fmt.Fprintln(os.Stderr, format.Stderr(err))
+			os.Exit(1)
+		}
 	}
 
-	// out/index.html
-	var contents string
-	if a.getCommandKind() == KindDevCommand {
-		bstr, err := os.ReadFile(filepath.Join(RETRO_OUT_DIR, "index.html"))
-		if err != nil {
-			return err
+	// www/_headers
+	headerRules, err := loadHeadersFile()
+	if err != nil {
+		var configErr ConfigError
+		if errors.As(err, &configErr) {
+			fmt.Fprintln(os.Stderr, format.Stderr(err))
+			os.Exit(1)
+		}
+		return err
+	}
+
+	// www/_redirects
+	redirectRules, redirectErrs, err := loadRedirectsFile()
+	if err != nil {
+		return err
+	}
+
+	// out/<name>.html
+	var (
+		contents      = map[string]string{} // Page names to HTML with server-sent events
+		pageFilenames = map[string]page{}
+	)
+	for _, p := range pages {
+		pageFilenames[p.htmlOutput()] = p
+		if a.getCommandKind() == KindDevCommand {
+			bstr, err := os.ReadFile(p.htmlOutput())
+			if err != nil {
+				return err
+			}
+			contents[p.name] = strings.Replace(string(bstr), "</body>", fmt.Sprintf("\t%s\n\t</body>", getHTMLServerSentEvents()), 1)
 		}
-		contents = strings.Replace(string(bstr), "</body>", fmt.Sprintf("\t%s\n\t</body>", htmlServerSentEvents), 1)
 	}
 
 	var (
@@ -198,42 +419,82 @@ func (a *App) Serve(options ServeOptions) error {
 		dev = <-options.Dev
 	}
 
+	resolver := newResolver(RETRO_OUT_DIR)
+
 	// Path for HTML and non-HTML resources
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Log to stdout
-		var nextLogMsg string
-		if dev.msg.IsDirty() {
-			nextLogMsg = dev.msg.String()
-		} else {
-			nextLogMsg = buildServeSuccessString(a.getPort(), dev.dur)
-		}
+		nextLogMsg := buildDevLogString(a.getPort(), dev)
 		if logMsg != nextLogMsg {
 			logMsg = nextLogMsg
 			terminal.Clear(os.Stdout)
 			fmt.Println(logMsg)
 		}
+		path, err := cleanRequestPath(r.URL)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		applyHeaderRules(w, headerRules, path)
 		// Log to the browser and eagerly return
 		if dev.msg.IsDirty() {
+			setHTMLRouteClass(r)
 			fmt.Fprintln(w, dev.msg.HTML())
 			return
 		}
-		// Serve non-HTML
-		path := getFilesystemPath(r.URL.Path)
-		if ext := filepath.Ext(path); ext != "" && ext != ".html" {
-			http.ServeFile(w, r, filepath.Join(RETRO_OUT_DIR, path))
+		// Redirect to the canonical path
+		if canonical := resolver.canonicalPath(path); canonical != path {
+			if r.URL.RawQuery != "" {
+				canonical += "?" + r.URL.RawQuery
+			}
+			code := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				code = http.StatusPermanentRedirect
+			}
+			http.Redirect(w, r, withBase(canonical), code)
+			return
+		}
+		// Redirect or rewrite before looking up files
+		path, status, done := applyRedirectRules(w, r, path, redirectRules)
+		if done {
+			return
+		} else if status != http.StatusOK {
+			w = &statusResponseWriter{ResponseWriter: w, status: status}
+		}
+		// Serve files
+		filename, err := resolver.filename(path)
+		p, isPage := pageFilenames[filename]
+		if err == nil && !isPage {
+			if filepath.Ext(filename) == ".html" {
+				setHTMLRouteClass(r)
+			}
+			serveFile(w, r, filename)
+			return
+		} else if errors.Is(err, errOutsideRoot) || (err != nil && filepath.Ext(path) != "" && filepath.Ext(path) != ".html") {
+			http.NotFound(w, r)
 			return
 		}
-		// Serve HTML
+		// Serve HTML for the page that serves path
+		setHTMLRouteClass(r)
+		if !isPage {
+			p = findPage(pages, path)
+		}
 		if a.getCommandKind() == KindDevCommand {
-			fmt.Fprint(w, contents)
+			fmt.Fprint(w, contents[p.name])
 			return
 		}
-		http.ServeFile(w, r, filepath.Join(RETRO_OUT_DIR, "index.html"))
+		serveFile(w, r, p.htmlOutput())
 	})
 
+	// Mount everything under the base path
+	http.Handle(RETRO_BASE, handleMetrics(stripBase(handler)))
+
+	// Paths for probes
+	handleEndpoints()
+
 	// Path for dev events
 	if a.getCommandKind() == KindDevCommand {
-		http.HandleFunc("/__dev__", func(w http.ResponseWriter, r *http.Request) {
+		http.HandleFunc(RETRO_BASE+"__dev__", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "text/event-stream")
 			w.Header().Set("Cache-Control", "no-cache")
 			w.Header().Set("Connection", "keep-alive")
@@ -254,17 +515,15 @@ func (a *App) Serve(options ServeOptions) error {
 	}
 
 	// Log to stdout
-	var nextLogMsg string
-	if dev.msg.IsDirty() {
-		nextLogMsg = dev.msg.String()
-	} else {
-		nextLogMsg = buildServeSuccessString(a.getPort(), dev.dur)
-	}
+	nextLogMsg := buildDevLogString(a.getPort(), dev)
 	if logMsg != nextLogMsg {
 		logMsg = nextLogMsg
 		terminal.Clear(os.Stdout)
 		fmt.Println(logMsg)
 	}
+	for _, err := range redirectErrs {
+		fmt.Fprintln(os.Stderr, format.Stderr(err))
+	}
 
 	port := a.getPort()
 	for {
@@ -308,7 +567,11 @@ func Run() {
 	// Command errors
 	switch err.(type) {
 	case cli.CommandError:
-		fmt.Fprintln(os.Stderr, format.Stderr(err))
+		if isBuildJSON(os.Args[1:]) {
+			must(newBuildJSONFailure(err, 0).write(os.Stdout))
+		} else {
+			fmt.Fprintln(os.Stderr, format.Stderr(err))
+		}
 		os.Exit(1)
 	default:
 		must(err)
@@ -322,6 +585,8 @@ func Run() {
 		err = app.Build(BuildOptions{WarmUpFlag: true})
 	case cli.ServeCommand:
 		err = app.Serve(ServeOptions{WarmUpFlag: true})
+	case cli.AnalyzeCommand:
+		err = app.Analyze(AnalyzeOptions{WarmUpFlag: true})
 	}
 	must(err)
 }
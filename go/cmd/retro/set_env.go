@@ -1,6 +1,12 @@
 package retro
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
 
 var (
 	NODE_ENV      = ""
@@ -8,8 +14,21 @@ var (
 	RETRO_WWW_DIR = ""
 	RETRO_SRC_DIR = ""
 	RETRO_OUT_DIR = ""
+	RETRO_BASE    = ""
+
+	RETRO_SOURCEMAP = ""
 )
 
+// Normalizes a base path to use leading and trailing slashes, e.g. 'app-name'
+// becomes '/app-name/'
+func normalizeBase(base string) string {
+	base = path.Clean("/" + strings.Trim(base, "/"))
+	if base != "/" {
+		base += "/"
+	}
+	return base
+}
+
 func setEnvImpl(errPointer *error, envKey, defaultValue string) {
 	if *errPointer != nil {
 		return
@@ -19,6 +38,12 @@ func setEnvImpl(errPointer *error, envKey, defaultValue string) {
 		envValue = defaultValue
 	}
 	switch envKey {
+	case "RETRO_BASE":
+		envValue = normalizeBase(envValue)
+	case "RETRO_WWW_DIR", "RETRO_SRC_DIR", "RETRO_OUT_DIR":
+		envValue = filepath.Clean(envValue)
+	}
+	switch envKey {
 	case "NODE_ENV":
 		NODE_ENV = envValue
 	case "RETRO_CMD":
@@ -29,6 +54,10 @@ func setEnvImpl(errPointer *error, envKey, defaultValue string) {
 		RETRO_SRC_DIR = envValue
 	case "RETRO_OUT_DIR":
 		RETRO_OUT_DIR = envValue
+	case "RETRO_BASE":
+		RETRO_BASE = envValue
+	case "RETRO_SOURCEMAP":
+		RETRO_SOURCEMAP = envValue
 	}
 	*errPointer = os.Setenv(envKey, envValue)
 }
@@ -39,21 +68,38 @@ func setEnvAndGlobalVariables(commandKind CommandKind) error {
 	case KindDevCommand:
 		setEnvImpl(&err, "NODE_ENV", "development")
 		setEnvImpl(&err, "RETRO_CMD", "dev")
-		setEnvImpl(&err, "RETRO_WWW_DIR", "www")
-		setEnvImpl(&err, "RETRO_SRC_DIR", "src")
-		setEnvImpl(&err, "RETRO_OUT_DIR", "out")
+		setEnvImpl(&err, "RETRO_WWW_DIR", userConfig.WWWDir)
+		setEnvImpl(&err, "RETRO_SRC_DIR", userConfig.SrcDir)
+		setEnvImpl(&err, "RETRO_OUT_DIR", userConfig.OutDir)
+		setEnvImpl(&err, "RETRO_BASE", userConfig.Base)
+		setEnvImpl(&err, "RETRO_SOURCEMAP", "linked")
 	case KindBuildCommand:
 		setEnvImpl(&err, "NODE_ENV", "production")
 		setEnvImpl(&err, "RETRO_CMD", "build")
-		setEnvImpl(&err, "RETRO_WWW_DIR", "www")
-		setEnvImpl(&err, "RETRO_SRC_DIR", "src")
-		setEnvImpl(&err, "RETRO_OUT_DIR", "out")
+		setEnvImpl(&err, "RETRO_WWW_DIR", userConfig.WWWDir)
+		setEnvImpl(&err, "RETRO_SRC_DIR", userConfig.SrcDir)
+		setEnvImpl(&err, "RETRO_OUT_DIR", userConfig.OutDir)
+		setEnvImpl(&err, "RETRO_BASE", userConfig.Base)
+		setEnvImpl(&err, "RETRO_SOURCEMAP", "linked")
+	case KindAnalyzeCommand:
+		// Analyze the same bundle as the build command
+		setEnvImpl(&err, "NODE_ENV", "production")
+		setEnvImpl(&err, "RETRO_CMD", "build")
+		setEnvImpl(&err, "RETRO_WWW_DIR", userConfig.WWWDir)
+		setEnvImpl(&err, "RETRO_SRC_DIR", userConfig.SrcDir)
+		setEnvImpl(&err, "RETRO_OUT_DIR", userConfig.OutDir)
+		setEnvImpl(&err, "RETRO_BASE", userConfig.Base)
+		setEnvImpl(&err, "RETRO_SOURCEMAP", "linked")
 	case KindServeCommand:
 		setEnvImpl(&err, "NODE_ENV", "production")
 		setEnvImpl(&err, "RETRO_CMD", "serve")
-		setEnvImpl(&err, "RETRO_WWW_DIR", "www")
-		setEnvImpl(&err, "RETRO_SRC_DIR", "src")
-		setEnvImpl(&err, "RETRO_OUT_DIR", "out")
+		setEnvImpl(&err, "RETRO_WWW_DIR", userConfig.WWWDir)
+		setEnvImpl(&err, "RETRO_SRC_DIR", userConfig.SrcDir)
+		setEnvImpl(&err, "RETRO_OUT_DIR", userConfig.OutDir)
+		setEnvImpl(&err, "RETRO_BASE", userConfig.Base)
+	}
+	if err == nil && RETRO_SOURCEMAP != "" && !isSourcemapMode(RETRO_SOURCEMAP) {
+		return newConfigError(fmt.Sprintf("RETRO_SOURCEMAP must be one of 'none', 'linked', 'external', 'inline', or 'hidden'; used '%s'.", RETRO_SOURCEMAP))
 	}
 	return err
 }
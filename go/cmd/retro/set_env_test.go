@@ -0,0 +1,24 @@
+package retro
+
+import (
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestNormalizeBase(t *testing.T) {
+	tests := []struct {
+		base string
+		want string
+	}{
+		{base: "", want: "/"},
+		{base: "/", want: "/"},
+		{base: "app", want: "/app/"},
+		{base: "/app", want: "/app/"},
+		{base: "app/", want: "/app/"},
+		{base: "/a/b/", want: "/a/b/"},
+	}
+	for _, test := range tests {
+		expect.DeepEqual(t, normalizeBase(test.base), test.want)
+	}
+}
@@ -0,0 +1,211 @@
+package retro
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zaydek/retro/go/cmd/retro/unix"
+	"github.com/zaydek/retro/go/pkg/manifest"
+	"github.com/zaydek/retro/go/pkg/terminal"
+)
+
+// The Markdown size diff filename. The diff is written next to the copy of the
+// manifest so it is never deployed.
+const sizeDiffFilename = "retro-size-diff.md"
+
+// The manifest to compare sizes to, if any
+var previousManifest *manifest.Manifest
+
+// A copy of the last production build's manifest outside of the output
+// directory. Sizes are compared to the copy when 'retro dev' replaced the
+// output directory or it was deleted.
+var previousManifestCopy = filepath.Join("node_modules", ".cache", "retro", manifest.Filename)
+
+// Writes the copy of the manifest used to compare sizes to the next build
+func writePreviousManifestCopy(m manifest.Manifest) error {
+	if err := os.MkdirAll(filepath.Dir(previousManifestCopy), 0755); err != nil {
+		return err
+	}
+	return m.Write(previousManifestCopy)
+}
+
+// Gets the Markdown size diff filename, e.g.
+// 'node_modules/.cache/retro/retro-size-diff.md'
+func getSizeDiffFilename() string {
+	return filepath.Join(filepath.Dir(previousManifestCopy), sizeDiffFilename)
+}
+
+// Reads the manifest to compare sizes to; '--diff', the previous production
+// build, or the copy of its manifest. Reads before 'warmUp' removes the output
+// directory.
+func readPreviousManifest(diffFilename string) (*manifest.Manifest, error) {
+	if diffFilename == "" {
+		for _, filename := range []string{filepath.Join(RETRO_OUT_DIR, manifest.Filename), previousManifestCopy} {
+			if m, err := manifest.Read(filename); err == nil && m.Mode == manifest.ModeBuild {
+				return &m, nil
+			}
+		}
+		return nil, nil
+	}
+	m, err := manifest.Read(diffFilename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, newConfigError(fmt.Sprintf("No manifest found at '%s'.", diffFilename))
+		}
+		return nil, newConfigError(fmt.Sprintf("Failed to read '%s'; %s.", diffFilename, strings.TrimSpace(err.Error())))
+	}
+	return &m, nil
+}
+
+// Matches esbuild content hashes, e.g. 'vendor__ABCD1234.js'
+var contentHashRegex = regexp.MustCompile(`(?:__|[.-])[A-Z0-9]{8}((?:\.[a-z0-9]+)+)$`)
+
+// Gets the filename without the content hash so outputs can be compared across
+// builds, e.g. 'vendor__ABCD1234.js' is 'vendor.js'
+func getLogicalFilename(path string) string {
+	return contentHashRegex.ReplaceAllString(path, "$1")
+}
+
+// Describes the size change of one logical output
+type sizeDelta struct {
+	path   string
+	before int64 // Zero when added
+	after  int64 // Zero when removed
+}
+
+func (d sizeDelta) delta() int64 {
+	return d.after - d.before
+}
+
+// Gets per-file deltas for changed outputs and the total delta. Sourcemaps are
+// ignored.
+func diffManifests(prev, next manifest.Manifest) ([]sizeDelta, sizeDelta) {
+	sizes := func(m manifest.Manifest) map[string]int64 {
+		ret := map[string]int64{}
+		for path, output := range m.Outputs {
+			if output.Kind == manifest.KindSourcemap {
+				continue
+			}
+			ret[getLogicalFilename(path)] += output.Bytes
+		}
+		return ret
+	}
+	before, after := sizes(prev), sizes(next)
+
+	paths := map[string]bool{}
+	for path := range before {
+		paths[path] = true
+	}
+	for path := range after {
+		paths[path] = true
+	}
+
+	var (
+		deltas []sizeDelta
+		total  = sizeDelta{path: "total"}
+	)
+	for path := range paths {
+		d := sizeDelta{path: path, before: before[path], after: after[path]}
+		total.before += d.before
+		total.after += d.after
+		if d.delta() != 0 {
+			deltas = append(deltas, d)
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].path < deltas[j].path })
+	return deltas, total
+}
+
+// Formats a signed number of bytes, e.g. '+1.2 KB'
+func formatDelta(n int64) string {
+	switch {
+	case n > 0:
+		return "+" + unix.HumanReadable(n)
+	case n < 0:
+		return "-" + unix.HumanReadable(-n)
+	}
+	return unix.HumanReadable(0)
+}
+
+func formatDeltaPercent(d sizeDelta) string {
+	if d.before == 0 {
+		return "new"
+	} else if d.after == 0 {
+		return "removed"
+	}
+	return fmt.Sprintf("%+.1f%%", float64(d.delta())/float64(d.before)*100)
+}
+
+func buildSizeDiffString(deltas []sizeDelta, total sizeDelta) string {
+	const sizeWidth = len("  +XXXX.X KB")
+
+	pathWidth := len(total.path)
+	for _, d := range deltas {
+		if len(d.path) > pathWidth {
+			pathWidth = len(d.path)
+		}
+	}
+	formatRow := func(d sizeDelta, color func(...interface{}) string) string {
+		deltaColor := terminal.Dim
+		if d.delta() > 0 {
+			deltaColor = terminal.Red
+		} else if d.delta() < 0 {
+			deltaColor = terminal.Green
+		}
+		return color(d.path) + strings.Repeat(" ", pathWidth-len(d.path)) +
+			deltaColor(fmt.Sprintf("%*s", sizeWidth, formatDelta(d.delta()))) +
+			terminal.Dimf("  %s", formatDeltaPercent(d))
+	}
+
+	var out string
+	out += fmt.Sprintln(terminal.Bold("Size changes"))
+	for _, d := range deltas {
+		out += fmt.Sprintln(formatRow(d, terminal.Dim))
+	}
+	out += fmt.Sprintln(formatRow(total, terminal.Bold))
+	return out
+}
+
+// Builds a Markdown table for code review
+func buildSizeDiffMarkdown(deltas []sizeDelta, total sizeDelta) string {
+	var out string
+	out += fmt.Sprintln("| File | Before | After | Change |")
+	out += fmt.Sprintln("| :--- | ---: | ---: | ---: |")
+	formatRow := func(path string, d sizeDelta) string {
+		return fmt.Sprintf("| %s | %s | %s | %s (%s) |",
+			path,
+			unix.HumanReadable(d.before),
+			unix.HumanReadable(d.after),
+			formatDelta(d.delta()),
+			formatDeltaPercent(d),
+		)
+	}
+	for _, d := range deltas {
+		out += fmt.Sprintln(formatRow("`"+d.path+"`", d))
+	}
+	out += fmt.Sprintln(formatRow("**Total**", total))
+	return out
+}
+
+// Compares the build to the previous manifest. Writes the Markdown size diff
+// and returns deltas for stdout. Does nothing without a previous manifest.
+func writeSizeDiff(next manifest.Manifest) (string, error) {
+	if previousManifest == nil {
+		return "", nil
+	}
+	deltas, total := diffManifests(*previousManifest, next)
+	markdown := buildSizeDiffMarkdown(deltas, total)
+	filename := getSizeDiffFilename()
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filename, []byte(markdown), 0644); err != nil {
+		return "", err
+	}
+	return buildSizeDiffString(deltas, total) + fmt.Sprintln(terminal.Dimf("Wrote %s", filename)), nil
+}
@@ -0,0 +1,109 @@
+package retro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+	"github.com/zaydek/retro/go/pkg/manifest"
+)
+
+func TestGetLogicalFilename(t *testing.T) {
+	expect.DeepEqual(t, getLogicalFilename("vendor__ABCD1234.js"), "vendor.js")
+	expect.DeepEqual(t, getLogicalFilename("client__ABCD1234.js.map"), "client.js.map")
+	expect.DeepEqual(t, getLogicalFilename("chunk.ABCD1234.js"), "chunk.js")
+	expect.DeepEqual(t, getLogicalFilename("www/logo.png"), "www/logo.png")
+}
+
+func TestDiffManifests(t *testing.T) {
+	prev := manifest.Manifest{Outputs: map[string]manifest.Output{
+		"vendor__AAAAAAAA.js":     {Kind: manifest.KindVendorJS, Bytes: 1000},
+		"vendor__AAAAAAAA.js.map": {Kind: manifest.KindSourcemap, Bytes: 5000},
+		"client__AAAAAAAA.js":     {Kind: manifest.KindClientJS, Bytes: 500},
+		"client__AAAAAAAA.css":    {Kind: manifest.KindClientCSS, Bytes: 100},
+	}}
+	next := manifest.Manifest{Outputs: map[string]manifest.Output{
+		"vendor__AAAAAAAA.js":     {Kind: manifest.KindVendorJS, Bytes: 1000},
+		"vendor__AAAAAAAA.js.map": {Kind: manifest.KindSourcemap, Bytes: 6000},
+		"client__BBBBBBBB.js":     {Kind: manifest.KindClientJS, Bytes: 600},
+		"chunk.CCCCCCCC.js":       {Kind: manifest.KindChunk, Bytes: 50},
+	}}
+	deltas, total := diffManifests(prev, next)
+	expect.DeepEqual(t, deltas, []sizeDelta{
+		{path: "chunk.js", before: 0, after: 50},
+		{path: "client.css", before: 100, after: 0},
+		{path: "client.js", before: 500, after: 600},
+	})
+	expect.DeepEqual(t, total, sizeDelta{path: "total", before: 1600, after: 1650})
+}
+
+func TestFormatDelta(t *testing.T) {
+	expect.DeepEqual(t, formatDelta(0), "0 B")
+	expect.DeepEqual(t, formatDelta(100), "+100 B")
+	expect.DeepEqual(t, formatDelta(-100), "-100 B")
+	expect.DeepEqual(t, formatDeltaPercent(sizeDelta{before: 200, after: 250}), "+25.0%")
+	expect.DeepEqual(t, formatDeltaPercent(sizeDelta{before: 0, after: 250}), "new")
+	expect.DeepEqual(t, formatDeltaPercent(sizeDelta{before: 250, after: 0}), "removed")
+}
+
+func TestBuildSizeDiffMarkdown(t *testing.T) {
+	deltas := []sizeDelta{{path: "client.js", before: 500, after: 600}}
+	total := sizeDelta{path: "total", before: 1500, after: 1600}
+	expect.DeepEqual(t, buildSizeDiffMarkdown(deltas, total), ""+
+		"| File | Before | After | Change |\n"+
+		"| :--- | ---: | ---: | ---: |\n"+
+		"| `client.js` | 500 B | 600 B | +100 B (+20.0%) |\n"+
+		"| **Total** | 1.5 KB | 1.6 KB | +100 B (+6.7%) |\n")
+}
+
+func TestReadPreviousManifest(t *testing.T) {
+	root := t.TempDir()
+	prevOut, prevCopy := RETRO_OUT_DIR, previousManifestCopy
+	t.Cleanup(func() { RETRO_OUT_DIR, previousManifestCopy = prevOut, prevCopy })
+	RETRO_OUT_DIR = filepath.Join(root, "out")
+	previousManifestCopy = filepath.Join(root, "cache", manifest.Filename)
+	must(os.MkdirAll(RETRO_OUT_DIR, 0755))
+
+	// No previous build
+	m, err := readPreviousManifest("")
+	must(err)
+	expect.DeepEqual(t, m, (*manifest.Manifest)(nil))
+
+	// Falls back to the copy when 'retro dev' replaced the output directory
+	must(writePreviousManifestCopy(manifest.Manifest{Mode: manifest.ModeBuild, DurationMs: 1}))
+	must(manifest.Manifest{Mode: manifest.ModeDev, DurationMs: 2}.Write(filepath.Join(RETRO_OUT_DIR, manifest.Filename)))
+	m, err = readPreviousManifest("")
+	must(err)
+	expect.DeepEqual(t, m.DurationMs, int64(1))
+
+	// Prefers the previous build
+	must(manifest.Manifest{Mode: manifest.ModeBuild, DurationMs: 3}.Write(filepath.Join(RETRO_OUT_DIR, manifest.Filename)))
+	m, err = readPreviousManifest("")
+	must(err)
+	expect.DeepEqual(t, m.DurationMs, int64(3))
+
+	// '--diff' must exist
+	_, err = readPreviousManifest(filepath.Join(root, "missing.json"))
+	expect.NotDeepEqual(t, err, nil)
+}
+
+func TestWriteSizeDiff(t *testing.T) {
+	root := t.TempDir()
+	prevOut, prevCopy, prevManifest := RETRO_OUT_DIR, previousManifestCopy, previousManifest
+	t.Cleanup(func() { RETRO_OUT_DIR, previousManifestCopy, previousManifest = prevOut, prevCopy, prevManifest })
+	RETRO_OUT_DIR = filepath.Join(root, "out")
+	previousManifestCopy = filepath.Join(root, "cache", manifest.Filename)
+	must(os.MkdirAll(RETRO_OUT_DIR, 0755))
+
+	previousManifest = &manifest.Manifest{Outputs: map[string]manifest.Output{"client.js": {Bytes: 500}}}
+	str, err := writeSizeDiff(manifest.Manifest{Outputs: map[string]manifest.Output{"client.js": {Bytes: 600}}})
+	must(err)
+	expect.NotDeepEqual(t, str, "")
+
+	// The diff is never written to the output directory
+	_, err = os.Stat(filepath.Join(root, "cache", sizeDiffFilename))
+	expect.DeepEqual(t, err, nil)
+	_, err = os.Stat(filepath.Join(RETRO_OUT_DIR, sizeDiffFilename))
+	expect.DeepEqual(t, os.IsNotExist(err), true)
+}
@@ -0,0 +1,70 @@
+package retro
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Checks mode is a sourcemap mode. 'none' writes no sourcemaps, 'linked'
+// writes '.map' files and 'sourceMappingURL' comments, 'external' writes '.map'
+// files without comments, 'inline' writes sourcemaps into outputs, and 'hidden'
+// writes '.map' files without comments outside of the output directory.
+func isSourcemapMode(mode string) bool {
+	switch mode {
+	case "none", "linked", "external", "inline", "hidden":
+		return true
+	}
+	return false
+}
+
+// Describes the sourcemap mode for the build summary
+func getSourcemapDescription() string {
+	if RETRO_SOURCEMAP == "hidden" {
+		return fmt.Sprintf("hidden in '%s'", getHiddenSourcemapDir())
+	}
+	return RETRO_SOURCEMAP
+}
+
+// Gets the directory hidden sourcemaps are moved to, e.g. 'out.sourcemaps'
+func getHiddenSourcemapDir() string {
+	return filepath.Clean(RETRO_OUT_DIR) + ".sourcemaps"
+}
+
+// Moves '.map' outputs from the output directory to the hidden sourcemap
+// directory so they are not deployed. Sourcemaps that were already moved are
+// skipped; watched rebuilds keep the vendor bundle of the first build.
+func hideSourcemaps(msg Message) error {
+	dir := getHiddenSourcemapDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := writeMarkerFile(dir); err != nil {
+		return err
+	}
+	for _, info := range []BundleInfo{msg.VendorInfo, msg.ClientInfo} {
+		meta, err := info.getMetafile()
+		if err != nil {
+			return err
+		}
+		for path := range meta.Outputs {
+			if filepath.Ext(path) != ".map" {
+				continue
+			} else if _, err := os.Stat(path); os.IsNotExist(err) {
+				continue
+			}
+			rel, err := filepath.Rel(RETRO_OUT_DIR, path)
+			if err != nil {
+				return err
+			}
+			target := filepath.Join(dir, rel)
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(path, target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package retro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestHideSourcemaps(t *testing.T) {
+	defer func(outDir string) { RETRO_OUT_DIR = outDir }(RETRO_OUT_DIR)
+	RETRO_OUT_DIR = filepath.Join(t.TempDir(), "out")
+
+	var (
+		js        = filepath.Join(RETRO_OUT_DIR, "client__AAAAAAAA.js")
+		sourcemap = filepath.Join(RETRO_OUT_DIR, "client__AAAAAAAA.js.map")
+	)
+	if err := os.MkdirAll(RETRO_OUT_DIR, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{js, sourcemap} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	msg := Message{ClientInfo: BundleInfo{Metafile: map[string]interface{}{
+		"outputs": map[string]interface{}{
+			js:        map[string]interface{}{},
+			sourcemap: map[string]interface{}{},
+		},
+	}}}
+	if err := hideSourcemaps(msg); err != nil {
+		t.Fatal(err)
+	}
+	_, err := os.Stat(js)
+	expect.DeepEqual(t, err, nil)
+	_, err = os.Stat(sourcemap)
+	expect.DeepEqual(t, os.IsNotExist(err), true)
+	_, err = os.Stat(filepath.Join(RETRO_OUT_DIR+".sourcemaps", "client__AAAAAAAA.js.map"))
+	expect.DeepEqual(t, err, nil)
+}
+
+func TestIsSourcemapMode(t *testing.T) {
+	for _, mode := range []string{"none", "linked", "external", "inline", "hidden"} {
+		expect.DeepEqual(t, isSourcemapMode(mode), true)
+	}
+	expect.DeepEqual(t, isSourcemapMode("both"), false)
+	expect.DeepEqual(t, isSourcemapMode(""), false)
+}
@@ -0,0 +1,41 @@
+package retro
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Checks whether generated tags have Subresource Integrity attributes. SRI is
+// on for 'retro build' unless 'retro.sri' is false and off for 'retro dev'.
+//
+// https://developer.mozilla.org/en-US/docs/Web/Security/Subresource_Integrity
+func isSRIEnabled() bool {
+	return RETRO_CMD == string(KindBuildCommand) && userConfig.SRI
+}
+
+// Computes the SHA-384 digest of a file, e.g. 'sha384-...'
+func getIntegrity(filename string) (string, error) {
+	bstr, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	sum := sha512.Sum384(bstr)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// Gets 'integrity' and 'crossorigin' attributes for an output relative to the
+// output directory, e.g. ' integrity="sha384-..." crossorigin="anonymous"'.
+// Returns an empty string when SRI is off or there is no output.
+func getIntegrityAttrs(entry string) (string, error) {
+	if !isSRIEnabled() || entry == "" {
+		return "", nil
+	}
+	integrity, err := getIntegrity(filepath.Join(RETRO_OUT_DIR, entry))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(` integrity="%s" crossorigin="anonymous"`, integrity), nil
+}
@@ -0,0 +1,46 @@
+package retro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestGetIntegrity(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "client.js")
+	must(os.WriteFile(filename, []byte("alert('Hello world')"), 0644))
+
+	integrity, err := getIntegrity(filename)
+	must(err)
+	expect.DeepEqual(t, integrity, "sha384-1cjczXuiiVpfrpjJOxiwNp5CscPE7jSpL/pXDFNwiuoQRQILX3VOc8kOq2DW/+dG")
+}
+
+func TestGetIntegrityAttrs(t *testing.T) {
+	root := t.TempDir()
+	prevOut, prevCmd, prevConfig := RETRO_OUT_DIR, RETRO_CMD, userConfig
+	t.Cleanup(func() { RETRO_OUT_DIR, RETRO_CMD, userConfig = prevOut, prevCmd, prevConfig })
+	RETRO_OUT_DIR, userConfig = root, newUserConfig()
+	must(os.WriteFile(filepath.Join(root, "client.js"), []byte("alert('Hello world')"), 0644))
+
+	RETRO_CMD = string(KindBuildCommand)
+	attrs, err := getIntegrityAttrs("client.js")
+	must(err)
+	expect.DeepEqual(t, attrs, ` integrity="sha384-1cjczXuiiVpfrpjJOxiwNp5CscPE7jSpL/pXDFNwiuoQRQILX3VOc8kOq2DW/+dG" crossorigin="anonymous"`)
+
+	attrs, err = getIntegrityAttrs("")
+	must(err)
+	expect.DeepEqual(t, attrs, "")
+
+	userConfig.SRI = false
+	attrs, err = getIntegrityAttrs("client.js")
+	must(err)
+	expect.DeepEqual(t, attrs, "")
+
+	userConfig.SRI = true
+	RETRO_CMD = string(KindDevCommand)
+	attrs, err = getIntegrityAttrs("client.js")
+	must(err)
+	expect.DeepEqual(t, attrs, "")
+}
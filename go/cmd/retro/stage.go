@@ -0,0 +1,152 @@
+package retro
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zaydek/retro/go/cmd/retro/cli"
+)
+
+// The output directory a staged build replaces, e.g. 'out'. Empty unless a
+// build is staged.
+var stagedOutDir string
+
+// Checks whether the build is staged. Production builds are written to a
+// sibling directory and swapped into place on success so a failed build keeps
+// the last good build. Analyzed builds are staged and always discarded so the
+// output directory is unchanged. Watched builds write in place.
+func (a *App) isStagedBuild() bool {
+	switch command := a.Command.(type) {
+	case cli.BuildCommand:
+		return !command.Watch
+	case cli.AnalyzeCommand:
+		return true
+	}
+	return false
+}
+
+// Gets the directories a build generates, e.g. 'out' and 'out.sourcemaps'
+func getGeneratedDirs() []string {
+	dirs := []string{RETRO_OUT_DIR}
+	if RETRO_SOURCEMAP == "hidden" {
+		dirs = append(dirs, getHiddenSourcemapDir())
+	}
+	return dirs
+}
+
+// Gets the staging directory for an output directory, e.g. 'out.staging'
+func getStagingDir(outDir string) string {
+	return filepath.Clean(outDir) + ".staging"
+}
+
+// Points the output directory to the staging directory. The backend reads
+// 'RETRO_OUT_DIR' so it writes to the staging directory, too, and defines
+// 'process.env.RETRO_OUT_DIR' from 'RETRO_PUBLIC_OUT_DIR' so bundles refer to
+// the output directory after the swap.
+func stageOutDir() error {
+	stagedOutDir = RETRO_OUT_DIR
+	RETRO_OUT_DIR = getStagingDir(stagedOutDir)
+	if err := os.Setenv("RETRO_PUBLIC_OUT_DIR", stagedOutDir); err != nil {
+		return err
+	}
+	return os.Setenv("RETRO_OUT_DIR", RETRO_OUT_DIR)
+}
+
+// Restores the output directory after a build is staged
+func unstageOutDir() error {
+	RETRO_OUT_DIR = stagedOutDir
+	stagedOutDir = ""
+	if err := os.Unsetenv("RETRO_PUBLIC_OUT_DIR"); err != nil {
+		return err
+	}
+	return os.Setenv("RETRO_OUT_DIR", RETRO_OUT_DIR)
+}
+
+// Rebases a path in the staging directory onto the output directory it
+// replaces, e.g. 'out.staging/client.js' to 'out/client.js', so checks report
+// paths that exist after the swap. Other paths are unchanged.
+func getUnstagedPath(path string) string {
+	if stagedOutDir == "" {
+		return path
+	}
+	rel, err := filepath.Rel(RETRO_OUT_DIR, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.Join(stagedOutDir, rel)
+}
+
+// Swaps the staging directories into place and restores the output directory
+func swapStagedOutDir() error {
+	if stagedOutDir == "" {
+		return nil
+	}
+	staged := getGeneratedDirs()
+	if err := unstageOutDir(); err != nil {
+		return err
+	}
+	for x, dir := range getGeneratedDirs() {
+		if err := swapDir(staged[x], dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Runs a staged build and swaps it into place when build succeeds. The staging
+// directories are discarded when build or the swap fails; discarding after the
+// swap is a no-op.
+func runStaged(build func() error) error {
+	return runStagedAndDiscard(func() error {
+		if err := build(); err != nil {
+			return err
+		}
+		return swapStagedOutDir()
+	})
+}
+
+// Runs a staged build and discards the staging directories afterwards
+func runStagedAndDiscard(build func() error) (err error) {
+	defer func() {
+		if discardErr := discardStagedOutDir(); err == nil {
+			err = discardErr
+		}
+	}()
+	return build()
+}
+
+// Discards the staging directories after a failed build so the last good build
+// is kept
+func discardStagedOutDir() error {
+	if stagedOutDir == "" {
+		return nil
+	}
+	for _, dir := range getGeneratedDirs() {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return unstageOutDir()
+}
+
+// Replaces dir with staged. The previous directory is renamed to a backup
+// directory first and deleted after the swap, so dir is only missing between
+// two renames. A failed swap restores the previous directory.
+func swapDir(staged, dir string) error {
+	backup := filepath.Clean(dir) + ".backup"
+	if err := guardRemoveDir(backup, true); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(backup); err != nil {
+		return err
+	}
+	if err := os.Rename(dir, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(staged, dir); err != nil {
+		os.Rename(backup, dir)
+		return err
+	}
+	return os.RemoveAll(backup)
+}
@@ -0,0 +1,150 @@
+package retro
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestSwapDir(t *testing.T) {
+	root := t.TempDir()
+	var (
+		staged = filepath.Join(root, "out.staging")
+		dir    = filepath.Join(root, "out")
+	)
+	for _, d := range []string{staged, dir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(d, "index.html"), []byte(filepath.Base(d)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	must(swapDir(staged, dir))
+	bstr, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	must(err)
+	expect.DeepEqual(t, string(bstr), "out.staging")
+
+	_, err = os.Stat(staged)
+	expect.DeepEqual(t, os.IsNotExist(err), true)
+	_, err = os.Stat(dir + ".backup")
+	expect.DeepEqual(t, os.IsNotExist(err), true)
+}
+
+func TestDiscardStagedOutDir(t *testing.T) {
+	root := t.TempDir()
+	prevOut, prevSourcemap := RETRO_OUT_DIR, RETRO_SOURCEMAP
+	t.Cleanup(func() {
+		RETRO_OUT_DIR, RETRO_SOURCEMAP = prevOut, prevSourcemap
+		os.Setenv("RETRO_OUT_DIR", prevOut)
+	})
+	RETRO_OUT_DIR, RETRO_SOURCEMAP = filepath.Join(root, "out"), "linked"
+
+	must(stageOutDir())
+	expect.DeepEqual(t, RETRO_OUT_DIR, filepath.Join(root, "out.staging"))
+	expect.DeepEqual(t, os.Getenv("RETRO_OUT_DIR"), filepath.Join(root, "out.staging"))
+	expect.DeepEqual(t, os.Getenv("RETRO_PUBLIC_OUT_DIR"), filepath.Join(root, "out"))
+	must(os.MkdirAll(RETRO_OUT_DIR, 0755))
+
+	must(discardStagedOutDir())
+	expect.DeepEqual(t, RETRO_OUT_DIR, filepath.Join(root, "out"))
+	expect.DeepEqual(t, os.Getenv("RETRO_PUBLIC_OUT_DIR"), "")
+	_, err := os.Stat(filepath.Join(root, "out.staging"))
+	expect.DeepEqual(t, os.IsNotExist(err), true)
+}
+
+func TestGetUnstagedPath(t *testing.T) {
+	prevOut, prevStaged := RETRO_OUT_DIR, stagedOutDir
+	t.Cleanup(func() { RETRO_OUT_DIR, stagedOutDir = prevOut, prevStaged })
+
+	RETRO_OUT_DIR, stagedOutDir = "out", ""
+	expect.DeepEqual(t, getUnstagedPath(filepath.Join("out", "client.js")), filepath.Join("out", "client.js"))
+
+	RETRO_OUT_DIR, stagedOutDir = "out.staging", "out"
+	expect.DeepEqual(t, getUnstagedPath(filepath.Join("out.staging", "client.js")), filepath.Join("out", "client.js"))
+	expect.DeepEqual(t, getUnstagedPath(filepath.Join("out.staging", "pages", "a.js")), filepath.Join("out", "pages", "a.js"))
+	expect.DeepEqual(t, getUnstagedPath(filepath.Join("src", "index.js")), filepath.Join("src", "index.js"))
+}
+
+func TestRunStaged(t *testing.T) {
+	root := t.TempDir()
+	prevOut, prevSourcemap := RETRO_OUT_DIR, RETRO_SOURCEMAP
+	t.Cleanup(func() {
+		RETRO_OUT_DIR, RETRO_SOURCEMAP = prevOut, prevSourcemap
+		os.Setenv("RETRO_OUT_DIR", prevOut)
+	})
+	RETRO_SOURCEMAP = "linked"
+	out := filepath.Join(root, "out")
+	must(os.MkdirAll(out, 0755))
+	must(os.WriteFile(filepath.Join(out, "index.html"), []byte("last good build"), 0644))
+
+	// Writes a staged build, then fails after it
+	stageAndBuild := func(fail error) error {
+		RETRO_OUT_DIR = out
+		must(stageOutDir())
+		return runStaged(func() error {
+			must(os.MkdirAll(RETRO_OUT_DIR, 0755))
+			must(os.WriteFile(filepath.Join(RETRO_OUT_DIR, "index.html"), []byte("next build"), 0644))
+			return fail
+		})
+	}
+
+	fail := errors.New("budgets failed")
+	expect.DeepEqual(t, stageAndBuild(fail), fail)
+	expect.DeepEqual(t, RETRO_OUT_DIR, out)
+	expect.DeepEqual(t, stagedOutDir, "")
+	_, err := os.Stat(getStagingDir(out))
+	expect.DeepEqual(t, os.IsNotExist(err), true)
+	bstr, err := os.ReadFile(filepath.Join(out, "index.html"))
+	must(err)
+	expect.DeepEqual(t, string(bstr), "last good build")
+
+	must(stageAndBuild(nil))
+	expect.DeepEqual(t, RETRO_OUT_DIR, out)
+	bstr, err = os.ReadFile(filepath.Join(out, "index.html"))
+	must(err)
+	expect.DeepEqual(t, string(bstr), "next build")
+}
+
+func TestRunStagedAndDiscard(t *testing.T) {
+	root := t.TempDir()
+	prevOut, prevSourcemap := RETRO_OUT_DIR, RETRO_SOURCEMAP
+	t.Cleanup(func() {
+		RETRO_OUT_DIR, RETRO_SOURCEMAP = prevOut, prevSourcemap
+		os.Setenv("RETRO_OUT_DIR", prevOut)
+	})
+	RETRO_OUT_DIR, RETRO_SOURCEMAP = filepath.Join(root, "out"), "linked"
+	must(os.MkdirAll(RETRO_OUT_DIR, 0755))
+	must(os.WriteFile(filepath.Join(RETRO_OUT_DIR, "index.html"), []byte("last build"), 0644))
+
+	must(stageOutDir())
+	must(runStagedAndDiscard(func() error {
+		must(os.MkdirAll(RETRO_OUT_DIR, 0755))
+		return os.WriteFile(filepath.Join(RETRO_OUT_DIR, "index.html"), []byte("analyzed build"), 0644)
+	}))
+	expect.DeepEqual(t, RETRO_OUT_DIR, filepath.Join(root, "out"))
+	_, err := os.Stat(filepath.Join(root, "out.staging"))
+	expect.DeepEqual(t, os.IsNotExist(err), true)
+	bstr, err := os.ReadFile(filepath.Join(RETRO_OUT_DIR, "index.html"))
+	must(err)
+	expect.DeepEqual(t, string(bstr), "last build")
+}
+
+func TestListSummaryRowsStaged(t *testing.T) {
+	root := t.TempDir()
+	prevOut, prevStaged := RETRO_OUT_DIR, stagedOutDir
+	t.Cleanup(func() { RETRO_OUT_DIR, stagedOutDir = prevOut, prevStaged })
+	RETRO_OUT_DIR, stagedOutDir = filepath.Join(root, "out.staging"), filepath.Join(root, "out")
+	must(os.MkdirAll(RETRO_OUT_DIR, 0755))
+	must(os.WriteFile(filepath.Join(RETRO_OUT_DIR, "index.html"), []byte("<!DOCTYPE html>"), 0644))
+
+	// Failed checks discard the build, so rows are listed before then
+	rows, err := listSummaryRows(RETRO_OUT_DIR)
+	must(err)
+	expect.DeepEqual(t, len(rows), 1)
+	expect.DeepEqual(t, rows[0].path, filepath.Join(root, "out", "index.html"))
+}
@@ -7,17 +7,52 @@ var usage = `
 
    Start the development server
 
-     --port=...  Use port number (default ` + terminal.Cyan("8000") + `)
+     --port=...            Use port number (default ` + terminal.Cyan("8000") + `)
+     --base=...            Use base path (default ` + terminal.Cyan("/") + `)
+     --src=...             Use source directory (default ` + terminal.Cyan("src") + `)
+     --www=...             Use static directory (default ` + terminal.Cyan("www") + `)
+     --out=...             Use output directory (default ` + terminal.Cyan("out") + `)
+     --sourcemap=...       Use sourcemap mode (default ` + terminal.Cyan("linked") + `)
+     --warnings-as-errors  Show warnings as errors in the browser
+     --force               Delete an output directory outside of the project
 
  ` + terminal.Bold("retro build") + `
 
    Build the production-ready build
 
+     --base=...            Use base path (default ` + terminal.Cyan("/") + `)
+     --src=...             Use source directory (default ` + terminal.Cyan("src") + `)
+     --www=...             Use static directory (default ` + terminal.Cyan("www") + `)
+     --out=...             Use output directory (default ` + terminal.Cyan("out") + `)
+     --sourcemap=...       Use sourcemap mode; one of ` + terminal.Cyan("none") + `, ` + terminal.Cyan("linked") + `, ` + terminal.Cyan("external") + `, ` + terminal.Cyan("inline") + `, or ` + terminal.Cyan("hidden") + `
+                           (default ` + terminal.Cyan("linked") + `)
+     --precompress         Write gzip and brotli files next to outputs
+     --strict-dedupe       Fail the build when a package is bundled more than once
+     --diff=...            Compare sizes to a manifest (default the previous build)
+     --json                Log one JSON document to stdout without colors
+     --watch               Rebuild on changes to the source directory
+     --warnings-as-errors  Fail the build on warnings
+     --force               Delete an output directory outside of the project
+
+   Exits ` + terminal.Cyan("0") + ` on success, ` + terminal.Cyan("1") + ` when the build fails, and ` + terminal.Cyan("2") + ` when a hard
+   budget or ` + terminal.Cyan("--strict-dedupe") + ` fails. Either way the last good build is kept
+
  ` + terminal.Bold("retro serve") + `
 
    Serve the production-ready build
 
      --port=...  Use port number (default ` + terminal.Cyan("8000") + `)
+     --base=...  Use base path (default ` + terminal.Cyan("/") + `)
+     --src=...   Use source directory (default ` + terminal.Cyan("src") + `)
+     --www=...   Use static directory (default ` + terminal.Cyan("www") + `)
+     --out=...   Use output directory (default ` + terminal.Cyan("out") + `)
+
+ ` + terminal.Bold("retro analyze") + `
+
+   Report which packages and modules make up the production build
+
+     --report=...  Write the HTML report to filename (default ` + terminal.Cyan("out/retro-analyze.html") + `)
+     --force       Delete an output directory outside of the project
 
  ` + terminal.Bold("Repositories") + `
 
@@ -0,0 +1,130 @@
+package retro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type ConfigError struct {
+	err error
+}
+
+func newConfigError(str string) ConfigError {
+	return ConfigError{err: errors.New(str)}
+}
+
+func (e ConfigError) Error() string {
+	return e.err.Error()
+}
+
+// Describes cache policies for 'retro serve'
+type CacheControlConfig struct {
+	Hashed   string `json:"hashed"`   // Cache-Control for hashed outputs
+	Unhashed string `json:"unhashed"` // Cache-Control for everything else
+}
+
+// Describes health, readiness, and build-info endpoints
+type EndpointsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Prefix  string `json:"prefix"` // Relative to the base path
+}
+
+// Describes the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"` // Relative to the base path
+}
+
+// Describes the serve command
+type ServeConfig struct {
+	CacheControl  CacheControlConfig `json:"cacheControl"`
+	Endpoints     EndpointsConfig    `json:"endpoints"`
+	Metrics       MetricsConfig      `json:"metrics"`
+	TrailingSlash string             `json:"trailingSlash"` // One of "add", "remove", or "" to ignore
+	HTMLExtension string             `json:"htmlExtension"` // One of "remove" or "" to ignore
+}
+
+// Describes Retro-specific configuration. This is read from the 'retro' key of
+// 'retro.config.js'; every other key is forwarded to esbuild.
+type UserConfig struct {
+	Base    string            `json:"base"`   // The public URL path, e.g. '/app-name/'
+	SrcDir  string            `json:"srcDir"` // The source directory, e.g. 'src'
+	WWWDir  string            `json:"wwwDir"` // The static directory, e.g. 'www'
+	OutDir  string            `json:"outDir"` // The output directory, e.g. 'out'
+	Pages   map[string]string `json:"pages"`  // Page names to JavaScript entry points, e.g. 'pricing' to 'src/pricing.js'
+	SRI     bool              `json:"sri"`    // Adds Subresource Integrity attributes to generated tags for 'retro build'
+	Budgets BudgetsConfig     `json:"budgets"`
+	Serve   ServeConfig       `json:"serve"`
+}
+
+func newUserConfig() UserConfig {
+	return UserConfig{
+		Base:   "/",
+		SrcDir: "src",
+		WWWDir: "www",
+		OutDir: "out",
+		SRI:    true,
+		Serve: ServeConfig{
+			CacheControl: CacheControlConfig{
+				Hashed:   "public, max-age=31536000, immutable",
+				Unhashed: "no-cache",
+			},
+			Endpoints: EndpointsConfig{
+				Enabled: true,
+				Prefix:  "/__retro/",
+			},
+			Metrics: MetricsConfig{
+				Enabled: false,
+				Path:    "/metrics",
+			},
+		},
+	}
+}
+
+var userConfig = newUserConfig()
+
+const userConfigFilename = "retro.config.js"
+
+// Evaluates 'retro.config.js' with Node.js because the config is a JavaScript
+// module
+const userConfigScript = `const config = require(require("path").join(process.cwd(), "retro.config"))
+console.log(JSON.stringify(config.retro || {}))`
+
+func loadUserConfig() error {
+	userConfig = newUserConfig() // Reset
+	if _, err := os.Stat(userConfigFilename); os.IsNotExist(err) {
+		return nil
+	}
+	var stderr strings.Builder
+	cmd := exec.Command("node", "-e", userConfigScript)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		return newConfigError(fmt.Sprintf("Failed to load '%s'.\n\n%s", userConfigFilename, strings.TrimSpace(stderr.String())))
+	}
+	if err := json.Unmarshal(stdout, &userConfig); err != nil {
+		return newConfigError(fmt.Sprintf("Failed to parse the 'retro' key of '%s'; %s.", userConfigFilename, err))
+	}
+	return userConfig.validate()
+}
+
+func (c UserConfig) validate() error {
+	switch c.Serve.TrailingSlash {
+	case "", "add", "remove":
+	default:
+		return newConfigError(fmt.Sprintf("'serve.trailingSlash' must be 'add' or 'remove' or empty; used '%s'.", c.Serve.TrailingSlash))
+	}
+	switch c.Serve.HTMLExtension {
+	case "", "remove":
+	default:
+		return newConfigError(fmt.Sprintf("'serve.htmlExtension' must be 'remove' or empty; used '%s'.", c.Serve.HTMLExtension))
+	}
+	if !strings.HasPrefix(c.Serve.Metrics.Path, "/") {
+		return newConfigError(fmt.Sprintf("'serve.metrics.path' must start with '/'; used '%s'.", c.Serve.Metrics.Path))
+	}
+	return nil
+}
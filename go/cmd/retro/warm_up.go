@@ -4,24 +4,74 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/zaydek/retro/go/cmd/retro/cli"
 	"github.com/zaydek/retro/go/cmd/retro/unix"
 )
 
-func warmUp(commandKind CommandKind) error {
-	if err := setEnvAndGlobalVariables(commandKind); err != nil { // Takes precedence
+func warmUp(a *App) error {
+	if err := a.setEnvFromFlags(); err != nil { // Takes precedence
+		return err
+	}
+	if err := loadUserConfig(); err != nil { // Provides defaults
+		return err
+	}
+	if err := setEnvAndGlobalVariables(a.getCommandKind()); err != nil {
+		return err
+	}
+	warningsAsErrors = a.getWarningsAsErrors()
+	if err := validateDirs(a.getCommandKind()); err != nil {
 		return err
 	}
 	if err := guardEntryPoints(); err != nil {
 		return err
 	}
-	if err := os.RemoveAll(RETRO_OUT_DIR); err != nil {
+	if err := setPagesEnv(pages); err != nil {
+		return err
+	}
+	if _, err := loadHeadersFile(); err != nil {
 		return err
 	}
+	if a.getCommandKind() == KindBuildCommand {
+		var err error
+		if previousManifest, err = readPreviousManifest(a.Command.(cli.BuildCommand).Diff); err != nil {
+			return err
+		}
+	}
+	for _, dir := range getGeneratedDirs() {
+		if err := guardRemoveDir(dir, a.getForce()); err != nil {
+			return err
+		}
+	}
+	if a.isStagedBuild() {
+		if err := stageOutDir(); err != nil {
+			return err
+		}
+		for _, dir := range getGeneratedDirs() {
+			if err := guardRemoveDir(dir, a.getForce()); err != nil {
+				return err
+			}
+		}
+	}
+	for _, dir := range getGeneratedDirs() {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
 	if err := os.MkdirAll(RETRO_OUT_DIR, 0755); err != nil {
 		return err
 	}
+	if err := writeMarkerFile(RETRO_OUT_DIR); err != nil {
+		return err
+	}
 	target := filepath.Join(RETRO_OUT_DIR, RETRO_WWW_DIR)
-	if err := unix.CopyRecursively(RETRO_WWW_DIR, target, []string{filepath.Join(RETRO_WWW_DIR, "index.html")}); err != nil {
+	excludes := []string{
+		filepath.Join(RETRO_WWW_DIR, "_headers"),
+		filepath.Join(RETRO_WWW_DIR, "_redirects"),
+	}
+	for _, p := range pages {
+		excludes = append(excludes, p.htmlEntryPoint())
+	}
+	if err := unix.CopyRecursively(RETRO_WWW_DIR, target, excludes); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,78 @@
+package manifest
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// The manifest filename relative to the output directory
+const Filename = "retro-manifest.json"
+
+// The manifest format version. Readers should reject manifests with a newer
+// version; manifests without a version are version 1.
+const Version = 1
+
+type Mode string
+
+const (
+	ModeDev   Mode = "dev"
+	ModeBuild Mode = "build"
+)
+
+type OutputKind string
+
+const (
+	KindVendorJS  OutputKind = "vendor-js"
+	KindClientJS  OutputKind = "client-js"
+	KindClientCSS OutputKind = "client-css"
+	KindChunk     OutputKind = "chunk"
+	KindSourcemap OutputKind = "sourcemap"
+	KindAsset     OutputKind = "asset"
+)
+
+// Describes one output file
+type Output struct {
+	Kind   OutputKind `json:"kind"`
+	Bytes  int64      `json:"bytes"`
+	Hash   string     `json:"hash"`   // SHA-256 of the contents
+	Inputs int        `json:"inputs"` // The number of inputs from the metafile
+}
+
+// Describes a build. Entries maps the logical filenames of entry points to
+// their hashed filenames. Chunks have no logical filename because esbuild names
+// them by content, e.g. 'chunk-ABCD1234.js'; they are only listed in Outputs
+// with the kind "chunk".
+type Manifest struct {
+	Version        int               `json:"version"`
+	Mode           Mode              `json:"mode"`
+	RetroVersion   string            `json:"retroVersion"`
+	EsbuildVersion string            `json:"esbuildVersion"`
+	BuiltAt        time.Time         `json:"builtAt"`
+	DurationMs     int64             `json:"durationMs"`
+	Entries        map[string]string `json:"entries"`         // Logical filenames to hashed filenames, e.g. 'client.js'
+	Outputs        map[string]Output `json:"outputs"`         // Paths relative to the output directory
+	Pages          []string          `json:"pages,omitempty"` // Page names, e.g. 'index' and 'docs/index'
+}
+
+// Reads a manifest from filename
+func Read(filename string) (Manifest, error) {
+	var manifest Manifest
+	bstr, err := os.ReadFile(filename)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if err := json.Unmarshal(bstr, &manifest); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, nil
+}
+
+// Writes a manifest to filename
+func (m Manifest) Write(filename string) error {
+	bstr, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(bstr, '\n'), 0644)
+}
@@ -0,0 +1,45 @@
+package manifest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zaydek/retro/go/pkg/expect"
+)
+
+func TestReadWrite(t *testing.T) {
+	m := Manifest{
+		Version:        Version,
+		Mode:           ModeBuild,
+		RetroVersion:   "0.1.0",
+		EsbuildVersion: "0.11.6",
+		BuiltAt:        time.Date(2021, 4, 1, 12, 30, 0, 0, time.UTC),
+		DurationMs:     42,
+		Entries: map[string]string{
+			"client.css": "client__ABCD1234.css",
+			"client.js":  "client__EFGH5678.js",
+			"vendor.js":  "vendor__IJKL9012.js",
+		},
+		Outputs: map[string]Output{
+			"client__EFGH5678.js":     {Kind: KindClientJS, Bytes: 1024, Hash: "abc", Inputs: 3},
+			"client__EFGH5678.js.map": {Kind: KindSourcemap, Bytes: 2048, Hash: "def", Inputs: 0},
+			"chunk-MNOP3456.js":       {Kind: KindChunk, Bytes: 512, Hash: "ghi", Inputs: 1},
+		},
+		Pages: []string{"index", "pricing"},
+	}
+	filename := filepath.Join(t.TempDir(), Filename)
+	if err := m.Write(filename); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect.DeepEqual(t, got, m)
+}
+
+func TestReadMissing(t *testing.T) {
+	_, err := Read(filepath.Join(t.TempDir(), Filename))
+	expect.NotDeepEqual(t, err, nil)
+}
@@ -0,0 +1,17 @@
+package terminal
+
+import (
+	"os"
+	"strconv"
+)
+
+// Gets the width of stdout in columns, falling back to $COLUMNS and then 80
+func Width() int {
+	if width := ioctlWidth(); width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 80
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !linux
+// +build !darwin,!linux
+
+package terminal
+
+func ioctlWidth() int {
+	return 0
+}
@@ -1,12 +1,17 @@
 import * as esbuild from "esbuild"
+import * as fs from "fs"
+import * as module from "module"
 import * as path from "path"
 import readline from "./readline"
 
 import {
 	clientConfigFromUserConfig,
+	prerenderConfigFromUserConfig,
 	vendorConfig,
 } from "./configs"
 
+import { RETRO_SRC_DIR } from "./env"
+
 let globalClientBundle: esbuild.BuildResult | esbuild.BuildIncremental | null = null
 
 interface BundleInfo {
@@ -78,10 +83,88 @@ async function rebuildClientBundle(userConfig: esbuild.BuildOptions): Promise<Bu
 	return clientInfo
 }
 
+interface RouteInfo {
+	Route: string
+	HTML: string
+}
+
+// Describes a failed route with esbuild's message type so Go formats it like
+// build errors
+function newRouteError(route: string, caught: any): esbuild.Message {
+	return {
+		pluginName: "",
+		text: `Failed to prerender route "${route}"; ${caught?.message ?? caught}`,
+		location: null,
+		notes: [],
+		detail: undefined,
+	}
+}
+
+// Prerenders the routes exported by 'src/App.js'. Apps without 'App.js' or a
+// 'routes' export are not prerendered.
+async function prerenderRoutes(userConfig: esbuild.BuildOptions): Promise<{ prerenderInfo: BundleInfo, routes: RouteInfo[] }> {
+	const prerenderInfo: BundleInfo = {
+		Metafile: null,
+		Warnings: [],
+		Errors: [],
+	}
+	const routes: RouteInfo[] = []
+	if (!fs.existsSync(path.join(RETRO_SRC_DIR, "App.js"))) {
+		return { prerenderInfo, routes }
+	}
+
+	// Bundle and evaluate the app
+	let exports: any = {}
+	try {
+		const bundle = await esbuild.build(prerenderConfigFromUserConfig(userConfig))
+		if (bundle.warnings.length > 0) { prerenderInfo.Warnings = bundle.warnings }
+		const output = bundle.outputFiles.find(file => file.path.endsWith(".js"))
+		// Require React APIs from the project, not from Retro
+		const projectRequire = module.createRequire(path.join(process.cwd(), "package.json"))
+		const globals = globalThis as any
+		globals.React = projectRequire("react")
+		globals.ReactDOM = projectRequire("react-dom")
+		globals.ReactDOMServer = projectRequire("react-dom/server")
+		const mod = { exports }
+		new Function("module", "exports", "require", output.text)(mod, mod.exports, projectRequire)
+		exports = mod.exports
+	} catch (caught) {
+		if (caught.errors?.length > 0) {
+			prerenderInfo.Errors = caught.errors
+		} else {
+			prerenderInfo.Errors = [newRouteError("*", caught)]
+		}
+		if (caught.warnings?.length > 0) { prerenderInfo.Warnings = caught.warnings }
+		return { prerenderInfo, routes }
+	}
+
+	// Render each route; 'routes' is an array or a function that returns an array
+	let paths: string[] = []
+	try {
+		paths = typeof exports.routes === "function" ? await exports.routes() : (exports.routes ?? [])
+	} catch (caught) {
+		prerenderInfo.Errors = [newRouteError("*", caught)]
+		return { prerenderInfo, routes }
+	}
+	const App = exports.App ?? exports.default
+	const { React, ReactDOMServer } = globalThis as any
+	for (const route of paths) {
+		try {
+			const html = ReactDOMServer.renderToString(React.createElement(App, { path: route }))
+			routes.push({ Route: route, HTML: html })
+		} catch (caught) {
+			prerenderInfo.Errors.push(newRouteError(route, caught))
+		}
+	}
+	return { prerenderInfo, routes }
+}
+
 async function main(): Promise<void> {
 	let userConfig: esbuild.BuildOptions = {}
 	try {
-		userConfig = require(path.join(process.cwd(), "retro.config"))
+		// The 'retro' key is read by Go and is not an esbuild option
+		const { retro, ...esbuildConfig } = require(path.join(process.cwd(), "retro.config"))
+		userConfig = esbuildConfig
 	} catch { }
 
 	esbuild.initialize({})
@@ -92,6 +175,7 @@ async function main(): Promise<void> {
 				const { vendorInfo, clientInfo } = await buildVendorAndClientBundles(userConfig)
 				console.log(
 					JSON.stringify({
+						esbuildVersion: esbuild.version,
 						vendorInfo,
 						clientInfo,
 					}),
@@ -106,6 +190,16 @@ async function main(): Promise<void> {
 				)
 				break
 			}
+			case "prerender": {
+				const { prerenderInfo, routes } = await prerenderRoutes(userConfig)
+				console.log(
+					JSON.stringify({
+						prerenderInfo,
+						routes,
+					}),
+				)
+				break
+			}
 			default:
 				throw new Error("Internal error")
 		}
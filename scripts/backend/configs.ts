@@ -3,12 +3,33 @@ import path from "path"
 
 import {
 	NODE_ENV,
+	RETRO_BASE,
 	RETRO_CMD,
 	RETRO_OUT_DIR,
+	RETRO_PAGES,
+	RETRO_PUBLIC_OUT_DIR,
+	RETRO_SOURCEMAP,
 	RETRO_SRC_DIR,
+	RETRO_WATCH,
 	RETRO_WWW_DIR,
 } from "./env"
 
+// Maps Retro's sourcemap modes to esbuild's. Hidden sourcemaps are written
+// without comments and moved out of the output directory by Go.
+const sourcemap: esbuild.BuildOptions["sourcemap"] = (() => {
+	switch (RETRO_SOURCEMAP) {
+		case "none":
+			return false
+		case "external":
+		case "hidden":
+			return "external"
+		case "inline":
+			return "inline"
+		default:
+			return true
+	}
+})()
+
 export const vendorConfig: esbuild.BuildOptions = {
 	bundle: true,
 	entryNames: NODE_ENV !== "production"
@@ -21,7 +42,8 @@ export const vendorConfig: esbuild.BuildOptions = {
 	metafile: true,
 	minify: NODE_ENV === "production",
 	outdir: RETRO_OUT_DIR,
-	sourcemap: true,
+	publicPath: RETRO_BASE,
+	sourcemap,
 }
 
 export const clientConfigFromUserConfig = (userConfig: esbuild.BuildOptions): esbuild.BuildOptions => ({
@@ -33,13 +55,16 @@ export const clientConfigFromUserConfig = (userConfig: esbuild.BuildOptions): es
 		"process.env.RETRO_CMD": JSON.stringify(RETRO_CMD),
 		"process.env.RETRO_WWW_DIR": JSON.stringify(RETRO_WWW_DIR),
 		"process.env.RETRO_SRC_DIR": JSON.stringify(RETRO_SRC_DIR),
-		"process.env.RETRO_OUT_DIR": JSON.stringify(RETRO_OUT_DIR),
+		"process.env.RETRO_OUT_DIR": JSON.stringify(RETRO_PUBLIC_OUT_DIR),
+		"process.env.RETRO_BASE": JSON.stringify(RETRO_BASE),
 	},
 	entryNames: NODE_ENV !== "production"
 		? undefined
 		: "[dir]/[name]__[hash]",
+	// One client entry point per page; every page shares the vendor bundle
 	entryPoints: {
 		...userConfig.entryPoints,
+		...RETRO_PAGES,
 		"client": path.join(RETRO_SRC_DIR, "index.js"),
 	},
 	external: [
@@ -48,7 +73,7 @@ export const clientConfigFromUserConfig = (userConfig: esbuild.BuildOptions): es
 		"react-dom",
 		"react-dom/server",
 	],
-	incremental: RETRO_CMD === "dev",
+	incremental: RETRO_CMD === "dev" || RETRO_WATCH,
 	inject: [
 		// Only React APIs are shimmed
 		path.join(__dirname, "require.js"),
@@ -61,5 +86,25 @@ export const clientConfigFromUserConfig = (userConfig: esbuild.BuildOptions): es
 	metafile: true,
 	minify: NODE_ENV === "production",
 	outdir: RETRO_OUT_DIR,
-	sourcemap: true,
+	publicPath: RETRO_BASE,
+	sourcemap,
+})
+
+// Bundles the app for Node.js so routes can be prerendered with
+// ReactDOMServer. React APIs are required from the project instead of shimmed.
+export const prerenderConfigFromUserConfig = (userConfig: esbuild.BuildOptions): esbuild.BuildOptions => ({
+	...clientConfigFromUserConfig(userConfig),
+	entryNames: undefined,
+	entryPoints: {
+		"prerender": path.join(RETRO_SRC_DIR, "App.js"),
+	},
+	format: "cjs",
+	incremental: false,
+	inject: [],
+	minify: false,
+	outdir: path.join(RETRO_OUT_DIR, "__prerender__"), // Not written
+	platform: "node",
+	sourcemap: false,
+	splitting: false,
+	write: false,
 })
@@ -37,3 +37,30 @@ export const RETRO_OUT_DIR = (() => {
 	}
 	return env
 })()
+
+// The output directory the app sees at runtime. Production builds are written
+// to a staging directory and swapped into 'RETRO_PUBLIC_OUT_DIR'; optional
+export const RETRO_PUBLIC_OUT_DIR = process.env["RETRO_PUBLIC_OUT_DIR"] || RETRO_OUT_DIR
+
+export const RETRO_BASE = (() => {
+	const env = process.env["RETRO_BASE"]
+	if (env === "") {
+		throw new Error(`process.env["RETRO_BASE"] === ""`)
+	}
+	return env
+})()
+
+export const RETRO_SOURCEMAP = (() => {
+	const env = process.env["RETRO_SOURCEMAP"]
+	if (env === "") {
+		throw new Error(`process.env["RETRO_SOURCEMAP"] === ""`)
+	}
+	return env
+})()
+
+// Set by 'retro build --watch'; optional
+export const RETRO_WATCH = process.env["RETRO_WATCH"] === "true"
+
+// Entry names to JavaScript entry points for every page other than the index
+// page, e.g. { "pages/pricing": "src/pages/pricing.js" }; optional
+export const RETRO_PAGES: Record<string, string> = JSON.parse(process.env["RETRO_PAGES"] || "{}")